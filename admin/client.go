@@ -0,0 +1,206 @@
+package admin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/MatusOllah/goreg"
+)
+
+var (
+	// ErrNotFound is matched by an [*Error] for a registry or an ID that doesn't exist.
+	ErrNotFound = errors.New("admin: not found")
+
+	// ErrReadOnly is matched by an [*Error] for a write rejected by a read-only server.
+	ErrReadOnly = errors.New("admin: read-only")
+)
+
+// Error is an error response from the server.
+type Error struct {
+	// Code is the error code, e.g. "notfound".
+	Code string
+
+	// Message is the error message.
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("admin: %s: %s", e.Code, e.Message)
+}
+
+// Is reports whether the error matches [ErrNotFound] or [ErrReadOnly].
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.Code == codeNotFound
+	case ErrReadOnly:
+		return e.Code == codeReadOnly
+	default:
+		return false
+	}
+}
+
+// Client is a client of the admin protocol. It is safe for concurrent use, but requests are sent one at a time.
+type Client struct {
+	conn net.Conn
+	sc   *bufio.Scanner
+
+	mu sync.Mutex
+}
+
+// Dial connects to the server listening on the Unix domain socket at path.
+func Dial(path string) (*Client, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn), nil
+}
+
+// NewClient creates a new [Client] using conn.
+func NewClient(conn net.Conn) *Client {
+	sc := bufio.NewScanner(conn)
+	sc.Buffer(nil, maxLineSize)
+	return &Client{conn: conn, sc: sc}
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// List returns the published registries, sorted by name.
+func (c *Client) List() ([]Info, error) {
+	var infos []Info
+	if err := c.do("LIST", &infos); err != nil {
+		return nil, err
+	}
+	return infos, nil
+}
+
+// Get returns the JSON-encoded value under the ID.
+func (c *Client) Get(name, id string) (json.RawMessage, error) {
+	var value json.RawMessage
+	if err := c.do("GET "+quoteArg(name)+" "+quoteArg(id), &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Set registers the value, encoded as JSON, under the ID.
+func (c *Client) Set(name, id string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return c.do("SET "+quoteArg(name)+" "+quoteArg(id)+" "+string(data), nil)
+}
+
+// Delete unregisters the ID.
+func (c *Client) Delete(name, id string) error {
+	return c.do("DEL "+quoteArg(name)+" "+quoteArg(id), nil)
+}
+
+// Dump returns all entries of the registry, sorted by ID, with JSON-encoded values.
+func (c *Client) Dump(name string) ([]goreg.Entry[json.RawMessage], error) {
+	var entries []goreg.Entry[json.RawMessage]
+	if err := c.do("DUMP "+quoteArg(name), &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Watch streams the changes of the registry, which must be a [goreg.FeedRegistry], calling fn for every change
+// until ctx is done, fn returns an error or the connection fails. It returns the first of these errors.
+//
+// Watch takes over the connection, so the client is closed when it returns.
+func (c *Client) Watch(ctx context.Context, name string, fn func(goreg.Change[json.RawMessage]) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.conn.Close()
+
+	stop := context.AfterFunc(ctx, func() {
+		c.conn.Close()
+	})
+	defer stop()
+
+	var seq uint64
+	if err := c.roundTrip("WATCH "+quoteArg(name), &seq); err != nil {
+		return ctxErr(ctx, err)
+	}
+
+	for c.sc.Scan() {
+		event, ok := strings.CutPrefix(c.sc.Text(), "EVENT ")
+		if !ok {
+			return parseResponse(c.sc.Text(), nil)
+		}
+		var change goreg.Change[json.RawMessage]
+		if err := json.Unmarshal([]byte(event), &change); err != nil {
+			return fmt.Errorf("admin: decoding event: %w", err)
+		}
+		if err := fn(change); err != nil {
+			return err
+		}
+	}
+	if err := c.sc.Err(); err != nil {
+		return ctxErr(ctx, err)
+	}
+	return ctxErr(ctx, io.ErrUnexpectedEOF)
+}
+
+// ctxErr returns the error of ctx if it is done, since closing the connection made err.
+func ctxErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+func (c *Client) do(req string, result any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.roundTrip(req, result)
+}
+
+func (c *Client) roundTrip(req string, result any) error {
+	if strings.ContainsAny(req, "\r\n") {
+		return errors.New("admin: request contains a line break")
+	}
+	if _, err := io.WriteString(c.conn, req+"\n"); err != nil {
+		return err
+	}
+	if !c.sc.Scan() {
+		if err := c.sc.Err(); err != nil {
+			return err
+		}
+		return io.ErrUnexpectedEOF
+	}
+	return parseResponse(c.sc.Text(), result)
+}
+
+// parseResponse parses a response line and decodes its result into result.
+func parseResponse(line string, result any) error {
+	status, rest, _ := strings.Cut(line, " ")
+	switch status {
+	case "OK":
+		if result == nil || rest == "" {
+			return nil
+		}
+		if err := json.Unmarshal([]byte(rest), result); err != nil {
+			return fmt.Errorf("admin: decoding response: %w", err)
+		}
+		return nil
+	case "ERR":
+		code, msg, _ := strings.Cut(rest, " ")
+		return &Error{Code: code, Message: msg}
+	default:
+		return fmt.Errorf("admin: invalid response %q", line)
+	}
+}
@@ -0,0 +1,128 @@
+package admin_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/goreg"
+	"github.com/MatusOllah/goreg/admin"
+)
+
+func TestClient(t *testing.T) {
+	reg := goreg.NewStandardRegistry[int]()
+	reg.Register("kozmeker", 69)
+	publish(t, "admin/client", reg)
+
+	c, err := admin.Dial(serve(t, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	infos, err := c.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, info := range infos {
+		if info.Name == "admin/client" {
+			found = true
+			if info.Len != 1 || info.ValueType != "int" || info.LockStats == nil {
+				t.Errorf("unexpected info %+v", info)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("expected admin/client in %+v", infos)
+	}
+
+	if err := c.Set("admin/client", "kajs\"mentke\n", 42); err != nil {
+		t.Fatal(err)
+	}
+	if val, _ := reg.Get("kajs\"mentke\n"); val != 42 {
+		t.Errorf("expected 42, got %d", val)
+	}
+	value, err := c.Get("admin/client", "kajs\"mentke\n")
+	if err != nil || string(value) != "42" {
+		t.Errorf("expected 42, got %s, %v", value, err)
+	}
+
+	if err := c.Delete("admin/client", "kozmeker"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get("admin/client", "kozmeker"); !errors.Is(err, admin.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := c.Dump("nope"); !errors.Is(err, admin.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	entries, err := c.Dump("admin/client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Key != "kajs\"mentke\n" || string(entries[0].Value) != "42" {
+		t.Errorf("unexpected dump %v", entries)
+	}
+}
+
+func TestClient_Watch(t *testing.T) {
+	feed := goreg.NewFeedRegistry[int](goreg.NewStandardRegistry[int](), nil)
+	feed.Register("kozmeker", 69)
+	publish(t, "admin/feed", feed)
+	path := serve(t, nil)
+
+	c, err := admin.Dial(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan goreg.Change[json.RawMessage], 10)
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- c.Watch(ctx, "admin/feed", func(change goreg.Change[json.RawMessage]) error {
+			events <- change
+			return nil
+		})
+	}()
+
+	// Mutate through another connection until the watcher is subscribed.
+	other, err := admin.Dial(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	var first goreg.Change[json.RawMessage]
+subscribe:
+	for i := 0; ; i++ {
+		if err := other.Set("admin/feed", "kajsmentke", i); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case first = <-events:
+			break subscribe
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if first.Op != goreg.OpRegister || first.ID != "kajsmentke" {
+		t.Errorf("unexpected change %+v", first)
+	}
+
+	feed.Unregister("kozmeker")
+	change := <-events
+	for change.ID == "kajsmentke" { // late events from subscribing
+		change = <-events
+	}
+	if change.Op != goreg.OpUnregister || change.ID != "kozmeker" || change.Seq != feed.Seq() {
+		t.Errorf("unexpected change %+v", change)
+	}
+
+	cancel()
+	if err := <-watchErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,465 @@
+// Package admin implements a line protocol for inspecting and patching the registries published with [goreg.Publish]
+// in a running process, over a Unix domain socket.
+//
+// Every request is a single line made of a command and its arguments separated by spaces. Names and IDs that contain
+// spaces, quotes or control characters are written as Go-quoted strings. The commands are:
+//
+//	LIST                    lists the published registries
+//	GET <name> <id>         returns the value under the ID
+//	SET <name> <id> <json>  registers the JSON value under the ID
+//	DEL <name> <id>         unregisters the ID
+//	DUMP <name>             returns all entries, sorted by ID
+//	WATCH <name>            streams the changes of a [goreg.FeedRegistry]
+//
+// Every response is a single line, either "OK" followed by an optional JSON result, or "ERR" followed by
+// an error code and a message. A successful WATCH returns the current sequence number of the feed,
+// and is followed by an "EVENT" line with a JSON-encoded [goreg.Change] for every change until the client disconnects.
+//
+// The protocol has no authentication of its own. [Server.ListenAndServe] makes the socket accessible only to
+// the user running the process.
+package admin
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/MatusOllah/goreg"
+)
+
+// maxLineSize is the maximum size of a request or response line.
+const maxLineSize = 64 << 20
+
+// Error codes.
+const (
+	codeBadRequest  = "badrequest"
+	codeNotFound    = "notfound"
+	codeReadOnly    = "readonly"
+	codeUnwatchable = "unwatchable"
+	codeTruncated   = "truncated"
+)
+
+// Info describes a published registry.
+type Info struct {
+	Name      string           `json:"name"`
+	Type      string           `json:"type"`
+	ValueType string           `json:"valueType"`
+	Len       int              `json:"len"`
+	LockStats *goreg.LockStats `json:"lockStats,omitempty"`
+}
+
+// Options configures a [Server].
+type Options struct {
+	// ReadOnly rejects SET and DEL requests.
+	ReadOnly bool
+}
+
+// Server serves the admin protocol.
+type Server struct {
+	opts Options
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closed    bool
+	wg        sync.WaitGroup
+}
+
+// NewServer creates a new [Server].
+//
+// A nil opts is equivalent to a zero [Options].
+func NewServer(opts *Options) *Server {
+	s := &Server{listeners: make(map[net.Listener]struct{}), conns: make(map[net.Conn]struct{})}
+	if opts != nil {
+		s.opts = *opts
+	}
+	return s
+}
+
+// ListenAndServe listens on a Unix domain socket at path and serves connections until the server is closed.
+// The directory holding the socket must not be accessible to other users, so that only the current user can
+// connect. It is created with permissions 0700 if it doesn't exist and removed again when ListenAndServe returns.
+// A socket left behind by a crashed process has to be removed before listening on the same path.
+// The socket is removed when ListenAndServe returns.
+func (s *Server) ListenAndServe(path string) error {
+	dir := filepath.Dir(path)
+	created, err := privateDir(dir)
+	if err != nil {
+		return err
+	}
+	if created {
+		defer os.Remove(dir)
+	}
+
+	// Nobody else can reach into the directory, so the socket can be bound right where it belongs.
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		l.Close()
+		return err
+	}
+	return s.Serve(l)
+}
+
+// privateDir makes sure that dir exists and is only accessible to the current user.
+// It reports whether it created dir.
+func privateDir(dir string) (bool, error) {
+	err := os.Mkdir(dir, 0o700)
+	if err == nil {
+		return true, nil
+	}
+	if !errors.Is(err, fs.ErrExist) {
+		return false, err
+	}
+
+	fi, err := os.Lstat(dir)
+	if err != nil {
+		return false, err
+	}
+	if !fi.IsDir() {
+		return false, fmt.Errorf("admin: %s is not a directory", dir)
+	}
+	if fi.Mode().Perm()&0o077 != 0 {
+		return false, fmt.Errorf("admin: %s is accessible to other users", dir)
+	}
+	return false, nil
+}
+
+// Serve accepts connections on l until the server is closed, which returns nil, or accepting fails.
+// Serve closes l when it returns.
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		l.Close()
+		return nil
+	}
+	s.listeners[l] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.listeners, l)
+		s.mu.Unlock()
+		l.Close()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return nil
+			}
+			return err
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return nil
+		}
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go func() {
+			defer s.wg.Done()
+			defer func() {
+				s.mu.Lock()
+				delete(s.conns, conn)
+				s.mu.Unlock()
+				conn.Close()
+			}()
+			s.serveConn(conn)
+		}()
+	}
+}
+
+// Close stops all listeners, closes all connections and waits for them to finish.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	for l := range s.listeners {
+		l.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return nil
+}
+
+// response is the result of a request.
+type response struct {
+	result    any
+	hasResult bool
+	code      string
+	msg       string
+}
+
+func ok(result any) response {
+	return response{result: result, hasResult: true}
+}
+
+func done() response {
+	return response{}
+}
+
+func fail(code, format string, args ...any) response {
+	return response{code: code, msg: fmt.Sprintf(format, args...)}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	sc := bufio.NewScanner(conn)
+	sc.Buffer(nil, maxLineSize)
+	w := bufio.NewWriter(conn)
+
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		cmd, rest, _ := strings.Cut(line, " ")
+
+		if strings.EqualFold(cmd, "WATCH") {
+			s.watch(conn, w, rest)
+			return
+		}
+		if err := writeResponse(w, s.handle(strings.ToUpper(cmd), rest)); err != nil {
+			return
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) handle(cmd, rest string) response {
+	switch cmd {
+	case "LIST":
+		infos := []Info{}
+		for _, reg := range goreg.Published() {
+			infos = append(infos, describe(reg))
+		}
+		return ok(infos)
+
+	case "GET":
+		reg, id, _, resp := lookup(rest, 2)
+		if reg == nil {
+			return resp
+		}
+		obj, found := reg.Get(id)
+		if !found {
+			return fail(codeNotFound, "object %q not found", id)
+		}
+		return ok(obj)
+
+	case "SET":
+		if s.opts.ReadOnly {
+			return fail(codeReadOnly, "server is read-only")
+		}
+		reg, id, value, resp := lookup(rest, 3)
+		if reg == nil {
+			return resp
+		}
+		if err := reg.Set(id, []byte(value)); err != nil {
+			return fail(codeBadRequest, "decoding value: %v", err)
+		}
+		return done()
+
+	case "DEL":
+		if s.opts.ReadOnly {
+			return fail(codeReadOnly, "server is read-only")
+		}
+		reg, id, _, resp := lookup(rest, 2)
+		if reg == nil {
+			return resp
+		}
+		if _, found := reg.Get(id); !found {
+			return fail(codeNotFound, "object %q not found", id)
+		}
+		reg.Delete(id)
+		return done()
+
+	case "DUMP":
+		reg, _, _, resp := lookup(rest, 1)
+		if reg == nil {
+			return resp
+		}
+		return ok(reg.Entries())
+
+	default:
+		return fail(codeBadRequest, "unknown command %q", cmd)
+	}
+}
+
+// watch streams the changes of a registry until the client disconnects.
+func (s *Server) watch(conn net.Conn, w *bufio.Writer, rest string) {
+	reg, _, _, resp := lookup(rest, 1)
+	if reg == nil {
+		writeResponse(w, resp)
+		w.Flush()
+		return
+	}
+	feed, hasFeed := reg.Feed()
+	if !hasFeed {
+		writeResponse(w, fail(codeUnwatchable, "registry %q has no change feed", reg.Name()))
+		w.Flush()
+		return
+	}
+
+	// The client doesn't send anything else, so a read returns when it disconnects.
+	done := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, conn)
+		close(done)
+	}()
+
+	changed := feed.Changed()
+	seq := feed.Seq()
+	if writeResponse(w, ok(seq)) != nil || w.Flush() != nil {
+		return
+	}
+	for {
+		select {
+		case <-changed:
+		case <-done:
+			return
+		}
+
+		changed = feed.Changed()
+		changes, err := feed.ChangesSince(seq)
+		if err != nil {
+			writeResponse(w, fail(codeTruncated, "watcher fell behind the change feed"))
+			w.Flush()
+			return
+		}
+		for _, c := range changes {
+			data, err := json.Marshal(c)
+			if err != nil {
+				writeResponse(w, fail(codeBadRequest, "encoding change: %v", err))
+				w.Flush()
+				return
+			}
+			if _, err := fmt.Fprintf(w, "EVENT %s\n", data); err != nil {
+				return
+			}
+			seq = c.Seq
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// lookup parses n arguments, the first of which is a registry name, and looks up the registry.
+// If n is 3, the last argument is the rest of the line. On failure, reg is nil and resp is the error response.
+func lookup(rest string, n int) (reg goreg.NamedRegistry, id, value string, resp response) {
+	args, err := parseArgs(rest, n)
+	if err != nil {
+		return nil, "", "", fail(codeBadRequest, "%v", err)
+	}
+	reg, found := goreg.Lookup(args[0])
+	if !found {
+		return nil, "", "", fail(codeNotFound, "registry %q not found", args[0])
+	}
+	if n > 1 {
+		id = args[1]
+		if id == "" {
+			return nil, "", "", fail(codeBadRequest, "empty ID")
+		}
+	}
+	if n > 2 {
+		value = args[2]
+	}
+	return reg, id, value, resp
+}
+
+// parseArgs parses exactly n space-separated arguments, which may be Go-quoted.
+// If n is 3, the last argument is the rest of the line.
+func parseArgs(s string, n int) ([]string, error) {
+	var args []string
+	for len(args) < n {
+		s = strings.TrimLeft(s, " ")
+		if s == "" {
+			return nil, fmt.Errorf("expected %d arguments, got %d", n, len(args))
+		}
+		if len(args) == 2 {
+			args = append(args, s)
+			s = ""
+			break
+		}
+
+		var arg string
+		if s[0] == '"' {
+			quoted, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted argument: %v", err)
+			}
+			arg, _ = strconv.Unquote(quoted)
+			s = s[len(quoted):]
+		} else {
+			arg, s, _ = strings.Cut(s, " ")
+		}
+		args = append(args, arg)
+	}
+	if strings.TrimSpace(s) != "" {
+		return nil, fmt.Errorf("expected %d arguments, got more", n)
+	}
+	return args, nil
+}
+
+// quoteArg quotes s if it can't be sent as a bare argument.
+func quoteArg(s string) string {
+	if s == "" || strings.ContainsFunc(s, func(r rune) bool { return r <= ' ' || r == '"' || r == 0x7f || !strconv.IsPrint(r) }) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func writeResponse(w io.Writer, resp response) error {
+	if resp.code != "" {
+		_, err := fmt.Fprintf(w, "ERR %s %s\n", resp.code, strings.ReplaceAll(resp.msg, "\n", " "))
+		return err
+	}
+	if !resp.hasResult {
+		_, err := io.WriteString(w, "OK\n")
+		return err
+	}
+	data, err := json.Marshal(resp.result)
+	if err != nil {
+		_, err = fmt.Fprintf(w, "ERR %s encoding result: %s\n", codeBadRequest, strings.ReplaceAll(err.Error(), "\n", " "))
+		return err
+	}
+	_, err = fmt.Fprintf(w, "OK %s\n", data)
+	return err
+}
+
+func describe(reg goreg.NamedRegistry) Info {
+	info := Info{Name: reg.Name(), Type: reg.Type(), ValueType: reg.ValueType(), Len: reg.Len()}
+	if stats, ok := reg.LockStats(); ok {
+		info.LockStats = &stats
+	}
+	return info
+}
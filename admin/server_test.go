@@ -0,0 +1,200 @@
+package admin_test
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/goreg"
+	"github.com/MatusOllah/goreg/admin"
+)
+
+func publish(t *testing.T, name string, reg goreg.Registry[int]) {
+	t.Helper()
+	if err := goreg.Publish(name, reg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { goreg.Unpublish(name) })
+}
+
+// serve starts a server on a socket in a private temporary directory and returns the path of the socket.
+func serve(t *testing.T, opts *admin.Options) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "run", "admin.sock")
+	srv := admin.NewServer(opts)
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.ListenAndServe(path)
+	}()
+	t.Cleanup(func() {
+		srv.Close()
+		if err := <-serveErr; err != nil {
+			t.Errorf("expected no error from ListenAndServe, got %v", err)
+		}
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the socket")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// session sends request lines over a raw connection and returns the response lines.
+func session(t *testing.T, path string, requests ...string) []string {
+	t.Helper()
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	sc := bufio.NewScanner(conn)
+	var responses []string
+	for _, req := range requests {
+		if _, err := conn.Write([]byte(req + "\n")); err != nil {
+			t.Fatal(err)
+		}
+		if !sc.Scan() {
+			t.Fatalf("no response to %q: %v", req, sc.Err())
+		}
+		responses = append(responses, sc.Text())
+	}
+	return responses
+}
+
+func TestServer(t *testing.T) {
+	reg := goreg.NewStandardRegistry[int]()
+	reg.Register("kozmeker", 69)
+	publish(t, "admin/items", reg)
+	path := serve(t, nil)
+
+	got := session(t, path,
+		`GET admin/items kozmeker`,
+		`set admin/items "kajs mentke" 42`,
+		`GET admin/items "kajs mentke"`,
+		`DUMP admin/items`,
+		`DEL admin/items kozmeker`,
+		`DEL admin/items kozmeker`,
+		`GET nope kozmeker`,
+		`SET admin/items kozmeker "nope"`,
+		`GET admin/items`,
+		`FROB`,
+		`WATCH admin/items`,
+	)
+	expected := []string{
+		`OK 69`,
+		`OK`,
+		`OK 42`,
+		`OK [{"key":"kajs mentke","value":42},{"key":"kozmeker","value":69}]`,
+		`OK`,
+		`ERR notfound object "kozmeker" not found`,
+		`ERR notfound registry "nope" not found`,
+		`ERR badrequest decoding value: json: cannot unmarshal string into Go value of type int`,
+		`ERR badrequest expected 2 arguments, got 1`,
+		`ERR badrequest unknown command "FROB"`,
+		`ERR unwatchable registry "admin/items" has no change feed`,
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("response %d: expected %q, got %q", i, expected[i], got[i])
+		}
+	}
+
+	list := session(t, path, "LIST")[0]
+	if !strings.Contains(list, `{"name":"admin/items","type":"*goreg.StandardRegistry[int]","valueType":"int","len":1,`) {
+		t.Errorf("unexpected LIST response %q", list)
+	}
+}
+
+func TestServer_ReadOnly(t *testing.T) {
+	reg := goreg.NewStandardRegistry[int]()
+	reg.Register("kozmeker", 69)
+	publish(t, "admin/readonly", reg)
+	path := serve(t, &admin.Options{ReadOnly: true})
+
+	c, err := admin.Dial(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if err := c.Set("admin/readonly", "kozmeker", 70); !errors.Is(err, admin.ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly, got %v", err)
+	}
+	if err := c.Delete("admin/readonly", "kozmeker"); !errors.Is(err, admin.ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly, got %v", err)
+	}
+	if val, _ := reg.Get("kozmeker"); val != 69 {
+		t.Errorf("expected 69, got %d", val)
+	}
+}
+
+func TestServer_Socket(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "run")
+	path := filepath.Join(dir, "admin.sock")
+
+	srv := admin.NewServer(nil)
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.ListenAndServe(path)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the server")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	for name, expected := range map[string]os.FileMode{dir: 0o700, path: 0o600} {
+		fi, err := os.Stat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := fi.Mode().Perm(); perm != expected {
+			t.Errorf("expected permissions %o for %s, got %o", expected, name, perm)
+		}
+	}
+
+	// A socket in use is not replaced.
+	if err := admin.NewServer(nil).ListenAndServe(path); err == nil {
+		t.Error("expected an error for a socket in use")
+	}
+
+	srv.Close()
+	if err := <-serveErr; err != nil {
+		t.Errorf("expected no error from ListenAndServe, got %v", err)
+	}
+
+	// Nothing is left behind.
+	if _, err := os.Stat(dir); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected the directory to be removed, got %v", err)
+	}
+}
+
+func TestServer_PublicDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Chmod(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := admin.NewServer(nil).ListenAndServe(filepath.Join(dir, "admin.sock")); err == nil {
+		t.Error("expected an error for a directory accessible to other users")
+	}
+}
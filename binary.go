@@ -0,0 +1,322 @@
+package goreg
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+// Compression is a compression algorithm used by [BinaryCodec].
+type Compression uint8
+
+const (
+	// CompressionNone disables compression. This is the default.
+	CompressionNone Compression = iota
+
+	// CompressionGzip compresses with [compress/gzip].
+	CompressionGzip
+
+	// CompressionFlate compresses with [compress/flate].
+	CompressionFlate
+)
+
+// String returns the name of the compression algorithm.
+func (c Compression) String() string {
+	switch c {
+	case CompressionNone:
+		return "none"
+	case CompressionGzip:
+		return "gzip"
+	case CompressionFlate:
+		return "flate"
+	default:
+		return fmt.Sprintf("Compression(%d)", c)
+	}
+}
+
+// ValueCodec encodes and decodes single values for [BinaryCodec].
+type ValueCodec[T any] interface {
+	// EncodeValue encodes obj.
+	EncodeValue(obj T) ([]byte, error)
+
+	// DecodeValue decodes a value encoded by EncodeValue.
+	DecodeValue(data []byte) (T, error)
+}
+
+// JSONValueCodec is a [ValueCodec] that encodes values with [encoding/json].
+type JSONValueCodec[T any] struct{}
+
+// EncodeValue encodes obj.
+func (JSONValueCodec[T]) EncodeValue(obj T) ([]byte, error) {
+	return json.Marshal(obj)
+}
+
+// DecodeValue decodes a value encoded by EncodeValue.
+func (JSONValueCodec[T]) DecodeValue(data []byte) (obj T, err error) {
+	err = json.Unmarshal(data, &obj)
+	return
+}
+
+// GobValueCodec is a [ValueCodec] that encodes values with [encoding/gob].
+// Every value carries its own type descriptors, so prefer [JSONValueCodec] or [BinaryValueCodec] for small values.
+type GobValueCodec[T any] struct{}
+
+// EncodeValue encodes obj.
+func (GobValueCodec[T]) EncodeValue(obj T) ([]byte, error) {
+	var bf bytes.Buffer
+	err := gob.NewEncoder(&bf).Encode(obj)
+	return bf.Bytes(), err
+}
+
+// DecodeValue decodes a value encoded by EncodeValue.
+func (GobValueCodec[T]) DecodeValue(data []byte) (obj T, err error) {
+	err = gob.NewDecoder(bytes.NewReader(data)).Decode(&obj)
+	return
+}
+
+// BinaryValueCodec is a [ValueCodec] for values that implement [encoding.BinaryMarshaler]
+// and whose pointers implement [encoding.BinaryUnmarshaler].
+type BinaryValueCodec[T any] struct{}
+
+// EncodeValue encodes obj.
+func (BinaryValueCodec[T]) EncodeValue(obj T) ([]byte, error) {
+	m, ok := any(obj).(encoding.BinaryMarshaler)
+	if !ok {
+		return nil, fmt.Errorf("goreg: %T does not implement encoding.BinaryMarshaler", obj)
+	}
+	return m.MarshalBinary()
+}
+
+// DecodeValue decodes a value encoded by EncodeValue.
+func (BinaryValueCodec[T]) DecodeValue(data []byte) (obj T, err error) {
+	u, ok := any(&obj).(encoding.BinaryUnmarshaler)
+	if !ok {
+		return obj, fmt.Errorf("goreg: %T does not implement encoding.BinaryUnmarshaler", &obj)
+	}
+	err = u.UnmarshalBinary(data)
+	return
+}
+
+const (
+	binaryMagic      = "GRBN"
+	binaryVersion    = 1
+	binaryHeaderSize = 18
+)
+
+// BinaryCodec is a [Codec] that encodes entries in a compact binary format.
+//
+// The output starts with an uncompressed header:
+//
+//	magic "GRBN" | version u8 | compression u8 | entry count u64 | CRC-32C of the body u32
+//
+// followed by the body, optionally compressed, in which every entry is stored as
+// a uvarint-prefixed ID followed by a uvarint-prefixed value encoded by the value codec.
+// All integers in the header are little-endian.
+type BinaryCodec[T any] struct {
+	// Values encodes the values. Defaults to [JSONValueCodec].
+	Values ValueCodec[T]
+
+	// Compression is the compression applied to the body. Defaults to [CompressionNone].
+	Compression Compression
+
+	// Level is the compression level, as in [compress/flate]. Zero means [flate.DefaultCompression],
+	// so [flate.NoCompression] can't be selected; use [CompressionNone] to store the body uncompressed.
+	Level int
+}
+
+func (c BinaryCodec[T]) values() ValueCodec[T] {
+	if c.Values == nil {
+		return JSONValueCodec[T]{}
+	}
+	return c.Values
+}
+
+// Encode writes the entries to w.
+func (c BinaryCodec[T]) Encode(w io.Writer, entries []Entry[T]) error {
+	values := c.values()
+
+	var body bytes.Buffer
+	var buf [binary.MaxVarintLen64]byte
+	for _, e := range entries {
+		val, err := values.EncodeValue(e.Value)
+		if err != nil {
+			return fmt.Errorf("goreg: encoding %q: %w", e.Key, err)
+		}
+
+		body.Write(buf[:binary.PutUvarint(buf[:], uint64(len(e.Key)))])
+		body.WriteString(e.Key)
+		body.Write(buf[:binary.PutUvarint(buf[:], uint64(len(val)))])
+		body.Write(val)
+	}
+
+	level := c.Level
+	if level == 0 {
+		level = flate.DefaultCompression
+	}
+
+	// The compressors don't write anything before the body, so an invalid compression or level
+	// is reported before the header is written.
+	var zw io.WriteCloser
+	var err error
+	switch c.Compression {
+	case CompressionNone:
+	case CompressionGzip:
+		zw, err = gzip.NewWriterLevel(w, level)
+	case CompressionFlate:
+		zw, err = flate.NewWriter(w, level)
+	default:
+		return fmt.Errorf("goreg: unknown compression %s", c.Compression)
+	}
+	if err != nil {
+		return err
+	}
+
+	var hdr [binaryHeaderSize]byte
+	copy(hdr[0:4], binaryMagic)
+	hdr[4] = binaryVersion
+	hdr[5] = byte(c.Compression)
+	binary.LittleEndian.PutUint64(hdr[6:14], uint64(len(entries)))
+	binary.LittleEndian.PutUint32(hdr[14:18], crc32.Checksum(body.Bytes(), crcTable))
+	if _, err := w.Write(hdr[:]); err != nil {
+		return err
+	}
+
+	if zw == nil {
+		_, err = body.WriteTo(w)
+		return err
+	}
+	if _, err := body.WriteTo(zw); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Decode reads entries from r. The compression is read from the header, so it doesn't have to match c.Compression.
+func (c BinaryCodec[T]) Decode(r io.Reader) ([]Entry[T], error) {
+	var entries []Entry[T]
+	err := c.streamEntries(r, false, func(e Entry[T], _ int64) error {
+		entries = append(entries, e)
+		return nil
+	})
+	return entries, err
+}
+
+func (c BinaryCodec[T]) streamEntries(r io.Reader, _ bool, fn func(e Entry[T], off int64) error) error {
+	values := c.values()
+
+	var hdr [binaryHeaderSize]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return &DecodeError{Index: -1, Offset: 0, Err: unexpectedEOF(err)}
+	}
+	if string(hdr[0:4]) != binaryMagic {
+		return &DecodeError{Index: -1, Offset: 0, Err: errors.New("not a binary registry")}
+	}
+	if hdr[4] != binaryVersion {
+		return &DecodeError{Index: -1, Offset: 4, Err: fmt.Errorf("%w: binary format version %d", ErrUnsupportedVersion, hdr[4])}
+	}
+	count := binary.LittleEndian.Uint64(hdr[6:14])
+	sum := binary.LittleEndian.Uint32(hdr[14:18])
+
+	var body io.Reader
+	switch comp := Compression(hdr[5]); comp {
+	case CompressionNone:
+		body = r
+	case CompressionGzip:
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return &DecodeError{Index: -1, Offset: binaryHeaderSize, Err: unexpectedEOF(err)}
+		}
+		defer zr.Close()
+		body = zr
+	case CompressionFlate:
+		zr := flate.NewReader(r)
+		defer zr.Close()
+		body = zr
+	default:
+		return &DecodeError{Index: -1, Offset: 5, Err: fmt.Errorf("unknown compression %s", comp)}
+	}
+
+	// Offsets are positions in the uncompressed body.
+	br := &binaryReader{r: bufio.NewReader(body), crc: crc32.New(crcTable)}
+	for i := uint64(0); i < count; i++ {
+		off := br.n
+
+		key, err := br.readBytes()
+		if err != nil {
+			return &DecodeError{Index: int(i), Offset: off, Err: err}
+		}
+		val, err := br.readBytes()
+		if err != nil {
+			return &DecodeError{Index: int(i), Key: string(key), Offset: off, Err: err}
+		}
+
+		obj, err := values.DecodeValue(val)
+		if err != nil {
+			return &DecodeError{Index: int(i), Key: string(key), Offset: off, Err: err}
+		}
+		if err := fn(Entry[T]{Key: string(key), Value: obj}, off); err != nil {
+			return err
+		}
+	}
+
+	if _, err := br.r.ReadByte(); err != io.EOF {
+		if err == nil {
+			err = errors.New("unexpected data after entries")
+		}
+		return &DecodeError{Index: -1, Offset: br.n, Err: err}
+	}
+	if br.crc.Sum32() != sum {
+		return &DecodeError{Index: -1, Offset: -1, Err: errFrameChecksum}
+	}
+
+	return nil
+}
+
+// binaryReader reads the uvarint-prefixed fields of a [BinaryCodec] body, keeping track of the offset and checksum.
+type binaryReader struct {
+	r   *bufio.Reader
+	crc hash.Hash32
+	n   int64
+}
+
+func (r *binaryReader) ReadByte() (byte, error) {
+	b, err := r.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	r.crc.Write([]byte{b})
+	r.n++
+	return b, nil
+}
+
+func (r *binaryReader) readBytes() ([]byte, error) {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if size > maxFrameSize {
+		return nil, fmt.Errorf("field too large (%d bytes)", size)
+	}
+
+	// Grow the buffer as data arrives instead of trusting the size up front.
+	data, err := io.ReadAll(io.LimitReader(r.r, int64(size)))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(data)) != size {
+		return nil, io.ErrUnexpectedEOF
+	}
+	r.crc.Write(data)
+	r.n += int64(size)
+	return data, nil
+}
@@ -0,0 +1,183 @@
+package goreg_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/MatusOllah/goreg"
+)
+
+func TestBinaryCodec(t *testing.T) {
+	testCodecRoundTrip(t, goreg.BinaryCodec[int]{})
+	testCodecRoundTrip(t, goreg.BinaryCodec[int]{Values: goreg.GobValueCodec[int]{}})
+	testCodecRoundTrip(t, goreg.BinaryCodec[int]{Compression: goreg.CompressionGzip})
+	testCodecRoundTrip(t, goreg.BinaryCodec[int]{Compression: goreg.CompressionFlate, Level: 9})
+}
+
+func TestBinaryCodec_Encode(t *testing.T) {
+	reg := goreg.NewOrderedRegistry[int]()
+	reg.Register("a", 42)
+
+	var bf bytes.Buffer
+	if err := goreg.Encode(reg, goreg.BinaryCodec[int]{}, &bf); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+
+	data := bf.Bytes()
+	if string(data[:4]) != "GRBN" {
+		t.Errorf("expected magic GRBN, got %q", data[:4])
+	}
+	if count := binary.LittleEndian.Uint64(data[6:14]); count != 1 {
+		t.Errorf("expected count 1, got %d", count)
+	}
+	if body := data[18:]; string(body) != "\x01a\x0242" {
+		t.Errorf("expected body %q, got %q", "\x01a\x0242", body)
+	}
+}
+
+type point struct {
+	X, Y uint8
+}
+
+func (p point) MarshalBinary() ([]byte, error) {
+	return []byte{p.X, p.Y}, nil
+}
+
+func (p *point) UnmarshalBinary(data []byte) error {
+	if len(data) != 2 {
+		return errors.New("invalid point")
+	}
+	p.X, p.Y = data[0], data[1]
+	return nil
+}
+
+func TestBinaryValueCodec(t *testing.T) {
+	reg := goreg.NewOrderedRegistry[point]()
+	reg.Register("spawn", point{1, 2})
+	reg.Register("exit", point{3, 4})
+
+	codec := goreg.BinaryCodec[point]{Values: goreg.BinaryValueCodec[point]{}}
+	var bf bytes.Buffer
+	if err := goreg.Encode(reg, codec, &bf); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+
+	newReg := goreg.NewOrderedRegistry[point]()
+	if err := goreg.Decode(newReg, codec, &bf); err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	if newReg.String() != reg.String() {
+		t.Errorf("expected %s, got %s", reg, newReg)
+	}
+
+	if err := goreg.Encode[int](goreg.NewOrderedRegistry[int](), goreg.BinaryCodec[int]{Values: goreg.BinaryValueCodec[int]{}}, &bf); err != nil {
+		t.Errorf("expected no error for an empty registry, got %v", err)
+	}
+}
+
+func TestBinaryCodec_Compression(t *testing.T) {
+	reg := goreg.NewStandardRegistry[string]()
+	for i := range 100 {
+		reg.Register("level"+strconv.Itoa(i), strings.Repeat("x", 100))
+	}
+
+	var plain, gzipped bytes.Buffer
+	if err := goreg.Encode[string](reg, goreg.BinaryCodec[string]{}, &plain); err != nil {
+		t.Fatal(err)
+	}
+	if err := goreg.Encode[string](reg, goreg.BinaryCodec[string]{Compression: goreg.CompressionGzip}, &gzipped); err != nil {
+		t.Fatal(err)
+	}
+	if gzipped.Len() >= plain.Len() {
+		t.Errorf("expected compressed output to be smaller, got %d >= %d", gzipped.Len(), plain.Len())
+	}
+
+	// The compression is read from the header.
+	newReg := goreg.NewStandardRegistry[string]()
+	if err := goreg.Decode[string](newReg, goreg.BinaryCodec[string]{}, &gzipped); err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	if !goreg.Equal[string](newReg, reg) {
+		t.Error("expected registries to be equal")
+	}
+}
+
+func TestBinaryCodec_EncodeInvalid(t *testing.T) {
+	entries := []goreg.Entry[int]{{Key: "kajsmentke", Value: 42}}
+	for _, codec := range []goreg.BinaryCodec[int]{
+		{Compression: goreg.Compression(99)},
+		{Compression: goreg.CompressionGzip, Level: 42},
+	} {
+		var bf bytes.Buffer
+		if err := codec.Encode(&bf, entries); err == nil {
+			t.Errorf("expected an error for %+v", codec)
+		}
+		if bf.Len() != 0 {
+			t.Errorf("expected nothing to be written for %+v, got %d bytes", codec, bf.Len())
+		}
+	}
+}
+
+func TestBinaryCodec_DecodeInvalid(t *testing.T) {
+	reg := goreg.NewOrderedRegistry[int]()
+	reg.Register("kozmeker", 69)
+	reg.Register("kajsmentke", 42)
+
+	var bf bytes.Buffer
+	if err := goreg.Encode(reg, goreg.BinaryCodec[int]{}, &bf); err != nil {
+		t.Fatal(err)
+	}
+	valid := bf.Bytes()
+
+	corrupt := bytes.Clone(valid)
+	corrupt[len(corrupt)-1] = '3'
+
+	trailing := append(bytes.Clone(valid), 0)
+
+	version := bytes.Clone(valid)
+	version[4] = 2
+
+	tests := map[string][]byte{
+		"Empty":     {},
+		"Magic":     []byte("GRBX" + string(valid[4:])),
+		"Version":   version,
+		"Truncated": valid[:len(valid)-1],
+		"Checksum":  corrupt,
+		"Trailing":  trailing,
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			var decErr *goreg.DecodeError
+			if _, err := (goreg.BinaryCodec[int]{}).Decode(bytes.NewReader(data)); !errors.As(err, &decErr) {
+				t.Errorf("expected DecodeError, got %v", err)
+			}
+		})
+	}
+}
+
+func FuzzBinaryCodec(f *testing.F) {
+	reg := goreg.NewOrderedRegistry[string]()
+	reg.Register("kozmeker", "69")
+	reg.Register("kajsmentke", "42")
+	for _, comp := range []goreg.Compression{goreg.CompressionNone, goreg.CompressionGzip, goreg.CompressionFlate} {
+		var bf bytes.Buffer
+		if err := goreg.Encode(reg, goreg.BinaryCodec[string]{Compression: comp}, &bf); err != nil {
+			f.Fatal(err)
+		}
+		f.Add(bf.Bytes())
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		reg := goreg.NewStandardRegistry[string]()
+		opts := &goreg.DecodeOptions[string]{Limits: fuzzLimits}
+		if err := goreg.DecodeWith(reg, goreg.BinaryCodec[string]{}, bytes.NewReader(data), opts); err != nil {
+			return
+		}
+		checkFuzzLimits[string](t, reg)
+	})
+}
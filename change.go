@@ -0,0 +1,121 @@
+package goreg
+
+import "fmt"
+
+// Op is a kind of registry mutation.
+type Op uint8
+
+const (
+	// OpRegister is a call to Register.
+	OpRegister Op = iota + 1
+
+	// OpUnregister is a call to Unregister.
+	OpUnregister
+
+	// OpReset is a call to Reset.
+	OpReset
+)
+
+// String returns the name of the operation.
+func (op Op) String() string {
+	switch op {
+	case OpRegister:
+		return "register"
+	case OpUnregister:
+		return "unregister"
+	case OpReset:
+		return "reset"
+	default:
+		return fmt.Sprintf("Op(%d)", uint8(op))
+	}
+}
+
+// MarshalText implements the [encoding.TextMarshaler] interface.
+func (op Op) MarshalText() ([]byte, error) {
+	switch op {
+	case OpRegister, OpUnregister, OpReset:
+		return []byte(op.String()), nil
+	default:
+		return nil, fmt.Errorf("goreg: invalid op %d", uint8(op))
+	}
+}
+
+// UnmarshalText implements the [encoding.TextUnmarshaler] interface.
+func (op *Op) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "register":
+		*op = OpRegister
+	case "unregister":
+		*op = OpUnregister
+	case "reset":
+		*op = OpReset
+	default:
+		return fmt.Errorf("goreg: invalid op %q", text)
+	}
+	return nil
+}
+
+// Change describes a single registry mutation.
+type Change[T any] struct {
+	// Seq is the sequence number of the change, if it is part of a numbered stream of changes.
+	Seq   uint64 `json:"seq,omitempty"`
+	Op    Op     `json:"op"`
+	ID    string `json:"id,omitempty"`
+	Value T      `json:"value,omitempty"`
+}
+
+// apply applies the change to reg.
+func (c Change[T]) apply(reg Registry[T]) error {
+	switch c.Op {
+	case OpRegister:
+		reg.Register(c.ID, c.Value)
+	case OpUnregister:
+		reg.Unregister(c.ID)
+	case OpReset:
+		reg.Reset()
+	default:
+		return fmt.Errorf("goreg: invalid op %d", uint8(c.Op))
+	}
+	return nil
+}
+
+// changeLog numbers changes and keeps the most recent ones in a ring buffer.
+// It is not safe for concurrent use.
+type changeLog[T any] struct {
+	seq  uint64 // sequence number of the last change
+	ring []Change[T]
+	head int // index of the oldest change in ring
+	n    int // number of changes in ring
+}
+
+func newChangeLog[T any](capacity int) changeLog[T] {
+	return changeLog[T]{ring: make([]Change[T], capacity)}
+}
+
+// add gives c the next sequence number, appends it, dropping the oldest change if the log is full, and returns it.
+func (l *changeLog[T]) add(c Change[T]) Change[T] {
+	l.seq++
+	c.Seq = l.seq
+	if l.n == len(l.ring) {
+		l.ring[l.head] = c
+		l.head = (l.head + 1) % len(l.ring)
+	} else {
+		l.ring[(l.head+l.n)%len(l.ring)] = c
+		l.n++
+	}
+	return c
+}
+
+// since returns a copy of the changes after the sequence number seq, oldest first.
+// ok is false if seq is ahead of the log or some of the changes were already dropped.
+func (l *changeLog[T]) since(seq uint64) (changes []Change[T], ok bool) {
+	if seq > l.seq || l.seq-seq > uint64(l.n) {
+		return nil, false
+	}
+	k := int(l.seq - seq)
+	changes = make([]Change[T], k)
+	for i := range changes {
+		changes[i] = l.ring[(l.head+l.n-k+i)%len(l.ring)]
+	}
+	return changes, true
+}
@@ -0,0 +1,52 @@
+package goreg_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/MatusOllah/goreg"
+)
+
+func TestOp_String(t *testing.T) {
+	tests := []struct {
+		op       goreg.Op
+		expected string
+	}{
+		{goreg.OpRegister, "register"},
+		{goreg.OpUnregister, "unregister"},
+		{goreg.OpReset, "reset"},
+		{goreg.Op(42), "Op(42)"},
+	}
+
+	for _, test := range tests {
+		if s := test.op.String(); s != test.expected {
+			t.Errorf("expected %s, got %s", test.expected, s)
+		}
+	}
+}
+
+func TestChange_JSON(t *testing.T) {
+	c := goreg.Change[int]{Op: goreg.OpRegister, ID: "kajsmentke", Value: 42}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("failed to marshal JSON: %v", err)
+	}
+
+	expected := `{"op":"register","id":"kajsmentke","value":42}`
+	if string(data) != expected {
+		t.Errorf("expected %s, got %s", expected, data)
+	}
+
+	var newC goreg.Change[int]
+	if err := json.Unmarshal(data, &newC); err != nil {
+		t.Fatalf("failed to unmarshal JSON: %v", err)
+	}
+	if newC != c {
+		t.Errorf("expected %v, got %v", c, newC)
+	}
+
+	if err := json.Unmarshal([]byte(`{"op":"explode"}`), &newC); err == nil {
+		t.Error("expected error for invalid op")
+	}
+}
@@ -0,0 +1,258 @@
+package goreg
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Entry is a key-value pair in a registry.
+type Entry[T any] struct {
+	Key   string `json:"key"`
+	Value T      `json:"value"`
+}
+
+// Codec encodes and decodes registry entries in a specific format.
+type Codec[T any] interface {
+	// Encode writes the entries to w.
+	Encode(w io.Writer, entries []Entry[T]) error
+
+	// Decode reads entries from r.
+	Decode(r io.Reader) ([]Entry[T], error)
+}
+
+// Encode encodes the objects in reg to w using codec.
+// Entries are encoded in iteration order, so the order of an [OrderedRegistry] is preserved.
+func Encode[T any](reg Registry[T], codec Codec[T], w io.Writer) error {
+	return codec.Encode(w, Entries(reg))
+}
+
+// Decode decodes objects from r using codec and replaces the contents of reg with them.
+// If decoding fails, reg is left untouched.
+//
+// It is equivalent to [DecodeWith] with nil options.
+func Decode[T any](reg Registry[T], codec Codec[T], r io.Reader) error {
+	return DecodeWith(reg, codec, r, nil)
+}
+
+// JSONCodec is a [Codec] that encodes entries as a JSON array of {"key", "value"} objects.
+// This is the default format of [OrderedRegistry.MarshalJSON].
+//
+// Decode accepts both the array format and the object format of [JSONObjectCodec].
+type JSONCodec[T any] struct {
+	// Indent is the indentation used for each level. An empty Indent produces compact output.
+	Indent string
+}
+
+// Encode writes the entries to w.
+func (c JSONCodec[T]) Encode(w io.Writer, entries []Entry[T]) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", c.Indent)
+	if entries == nil {
+		entries = []Entry[T]{}
+	}
+	return enc.Encode(entries)
+}
+
+// Decode reads entries from r. Trailing data after the entries is an error.
+func (c JSONCodec[T]) Decode(r io.Reader) ([]Entry[T], error) {
+	return decodeJSON[T](r)
+}
+
+func (c JSONCodec[T]) streamEntries(r io.Reader, strict bool, fn func(e Entry[T], off int64) error) error {
+	return streamJSON(r, strict, fn)
+}
+
+// JSONObjectCodec is a [Codec] that encodes entries as a plain JSON object, such as {"level1": ..., "level2": ...}.
+// This is the format of [StandardRegistry.MarshalJSON], but the order of the entries is preserved in both directions.
+//
+// Decode accepts both the object format and the array format of [JSONCodec].
+type JSONObjectCodec[T any] struct {
+	// Indent is the indentation used for each level. An empty Indent produces compact output.
+	Indent string
+}
+
+// Encode writes the entries to w.
+func (c JSONObjectCodec[T]) Encode(w io.Writer, entries []Entry[T]) error {
+	data, err := marshalJSONObject(entries)
+	if err != nil {
+		return err
+	}
+
+	if c.Indent != "" {
+		var bf bytes.Buffer
+		if err := json.Indent(&bf, data, "", c.Indent); err != nil {
+			return err
+		}
+		data = bf.Bytes()
+	}
+
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// Decode reads entries from r. Trailing data after the entries is an error.
+func (c JSONObjectCodec[T]) Decode(r io.Reader) ([]Entry[T], error) {
+	return decodeJSON[T](r)
+}
+
+func (c JSONObjectCodec[T]) streamEntries(r io.Reader, strict bool, fn func(e Entry[T], off int64) error) error {
+	return streamJSON(r, strict, fn)
+}
+
+func marshalJSONObject[T any](entries []Entry[T]) ([]byte, error) {
+	var bf bytes.Buffer
+	bf.WriteByte('{')
+	for i, e := range entries {
+		if i > 0 {
+			bf.WriteByte(',')
+		}
+
+		key, err := json.Marshal(e.Key)
+		if err != nil {
+			return nil, err
+		}
+		val, err := json.Marshal(e.Value)
+		if err != nil {
+			return nil, fmt.Errorf("goreg: encoding %q: %w", e.Key, err)
+		}
+
+		bf.Write(key)
+		bf.WriteByte(':')
+		bf.Write(val)
+	}
+	bf.WriteByte('}')
+
+	return bf.Bytes(), nil
+}
+
+// decodeJSON decodes entries in either the array or the object format from r.
+func decodeJSON[T any](r io.Reader) ([]Entry[T], error) {
+	var entries []Entry[T]
+	err := streamJSON(r, false, func(e Entry[T], _ int64) error {
+		entries = append(entries, e)
+		return nil
+	})
+	return entries, err
+}
+
+// streamJSON decodes entries in either the array or the object format from r and calls fn for every entry
+// along with the offset where it starts. Trailing data after the entries is an error.
+// If strict is true, unknown fields are rejected.
+func streamJSON[T any](r io.Reader, strict bool, fn func(e Entry[T], off int64) error) error {
+	dec := json.NewDecoder(r)
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+
+	if err := decodeJSONStream(dec, fn); err != nil {
+		return err
+	}
+
+	off := dec.InputOffset()
+	if _, err := dec.Token(); err != io.EOF {
+		return &DecodeError{Index: -1, Offset: off, Err: errors.New("unexpected data after entries")}
+	}
+
+	return nil
+}
+
+// decodeJSONStream decodes entries in either the array or the object format token by token
+// and calls fn for every entry as soon as it is decoded, along with the offset where it starts.
+// A JSON null decodes to no entries.
+func decodeJSONStream[T any](dec *json.Decoder, fn func(e Entry[T], off int64) error) error {
+	off := dec.InputOffset()
+	tok, err := dec.Token()
+	if err != nil {
+		return &DecodeError{Index: -1, Offset: off, Err: unexpectedEOF(err)}
+	}
+
+	i := 0
+	switch tok {
+	case json.Delim('['):
+		for ; dec.More(); i++ {
+			off := entryOffset(dec)
+
+			var e Entry[T]
+			if err := dec.Decode(&e); err != nil {
+				return &DecodeError{Index: i, Offset: off, Err: unexpectedEOF(err)}
+			}
+			if err := fn(e, off); err != nil {
+				return err
+			}
+		}
+	case json.Delim('{'):
+		for ; dec.More(); i++ {
+			off := entryOffset(dec)
+
+			tok, err := dec.Token()
+			if err != nil {
+				return &DecodeError{Index: i, Offset: off, Err: unexpectedEOF(err)}
+			}
+			key := tok.(string) // object keys are always strings
+
+			var obj T
+			if err := dec.Decode(&obj); err != nil {
+				return &DecodeError{Index: i, Key: key, Offset: off, Err: unexpectedEOF(err)}
+			}
+			if err := fn(Entry[T]{Key: key, Value: obj}, off); err != nil {
+				return err
+			}
+		}
+	case nil:
+		return nil
+	default:
+		return &DecodeError{Index: -1, Offset: off, Err: errors.New("expected JSON array or object")}
+	}
+
+	// Closing delimiter.
+	off = dec.InputOffset()
+	if _, err := dec.Token(); err != nil {
+		return &DecodeError{Index: i, Offset: off, Err: unexpectedEOF(err)}
+	}
+
+	return nil
+}
+
+// entryOffset returns the offset of the next value in dec, skipping whitespace and separators.
+func entryOffset(dec *json.Decoder) int64 {
+	off := dec.InputOffset()
+	if br, ok := dec.Buffered().(io.ByteReader); ok {
+		for {
+			c, err := br.ReadByte()
+			if err != nil || (c != ',' && c != ' ' && c != '\t' && c != '\n' && c != '\r') {
+				break
+			}
+			off++
+		}
+	}
+	return off
+}
+
+// unexpectedEOF turns [io.EOF] into [io.ErrUnexpectedEOF] for input that ended in the middle of the entries.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// GobCodec is a [Codec] that encodes entries with [encoding/gob].
+type GobCodec[T any] struct{}
+
+// Encode writes the entries to w.
+func (c GobCodec[T]) Encode(w io.Writer, entries []Entry[T]) error {
+	return gob.NewEncoder(w).Encode(entries)
+}
+
+// Decode reads entries from r.
+func (c GobCodec[T]) Decode(r io.Reader) ([]Entry[T], error) {
+	var entries []Entry[T]
+	if err := gob.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,128 @@
+package goreg_test
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/MatusOllah/goreg"
+)
+
+func testCodecRoundTrip(t *testing.T, codec goreg.Codec[int]) {
+	t.Helper()
+
+	reg := goreg.NewOrderedRegistry[int]()
+	reg.Register("kozmeker", 69)
+	reg.Register("kajsmentke", 42)
+
+	var bf bytes.Buffer
+	if err := goreg.Encode(reg, codec, &bf); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+
+	newReg := goreg.NewOrderedRegistry[int]()
+	newReg.Register("invalid", 0)
+	if err := goreg.Decode(newReg, codec, &bf); err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+
+	if newReg.String() != reg.String() {
+		t.Errorf("expected %s, got %s", reg, newReg)
+	}
+}
+
+func TestJSONCodec(t *testing.T) {
+	testCodecRoundTrip(t, goreg.JSONCodec[int]{})
+	testCodecRoundTrip(t, goreg.JSONCodec[int]{Indent: "\t"})
+}
+
+func TestJSONCodec_Encode(t *testing.T) {
+	reg := goreg.NewOrderedRegistry[int]()
+	reg.Register("kajsmentke", 42)
+
+	var bf bytes.Buffer
+	if err := goreg.Encode(reg, goreg.JSONCodec[int]{}, &bf); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+
+	expected := `[{"key":"kajsmentke","value":42}]` + "\n"
+	if bf.String() != expected {
+		t.Errorf("expected %s, got %s", expected, bf.String())
+	}
+}
+
+func TestJSONCodec_DecodeTrailingData(t *testing.T) {
+	_, err := goreg.JSONCodec[int]{}.Decode(bytes.NewBufferString(`[] []`))
+	if err == nil {
+		t.Error("expected error for trailing data")
+	}
+}
+
+func TestJSONObjectCodec(t *testing.T) {
+	testCodecRoundTrip(t, goreg.JSONObjectCodec[int]{})
+	testCodecRoundTrip(t, goreg.JSONObjectCodec[int]{Indent: "\t"})
+}
+
+func TestJSONObjectCodec_Encode(t *testing.T) {
+	reg := goreg.NewOrderedRegistry[int]()
+	reg.Register("kozmeker", 69)
+	reg.Register("kajsmentke", 42)
+
+	var bf bytes.Buffer
+	if err := goreg.Encode(reg, goreg.JSONObjectCodec[int]{}, &bf); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+
+	expected := `{"kozmeker":69,"kajsmentke":42}` + "\n"
+	if bf.String() != expected {
+		t.Errorf("expected %s, got %s", expected, bf.String())
+	}
+}
+
+func TestJSONCodec_DecodeFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected []goreg.Entry[int]
+	}{
+		{"Array", `[{"key":"b","value":2},{"key":"a","value":1}]`, []goreg.Entry[int]{{Key: "b", Value: 2}, {Key: "a", Value: 1}}},
+		{"Object", `{"b":2,"a":1}`, []goreg.Entry[int]{{Key: "b", Value: 2}, {Key: "a", Value: 1}}},
+		{"Null", `null`, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entries, err := goreg.JSONCodec[int]{}.Decode(bytes.NewBufferString(test.data))
+			if err != nil {
+				t.Fatalf("failed to decode: %v", err)
+			}
+			if !slices.Equal(entries, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, entries)
+			}
+		})
+	}
+}
+
+func TestJSONCodec_DecodeInvalid(t *testing.T) {
+	for _, data := range []string{``, `[`, `{"a":`, `{"a":1`, `42`, `"a"`} {
+		if _, err := (goreg.JSONCodec[int]{}).Decode(bytes.NewBufferString(data)); err == nil {
+			t.Errorf("expected error for %q", data)
+		}
+	}
+}
+
+func TestGobCodec(t *testing.T) {
+	testCodecRoundTrip(t, goreg.GobCodec[int]{})
+}
+
+func TestDecode_Error(t *testing.T) {
+	reg := goreg.NewStandardRegistry[int]()
+	reg.Register("kajsmentke", 42)
+
+	if err := goreg.Decode[int](reg, goreg.JSONCodec[int]{}, bytes.NewBufferString(`[{"key":`)); err == nil {
+		t.Error("expected error for invalid input")
+	}
+	if reg.Len() != 1 {
+		t.Errorf("expected registry to be untouched, got length %d", reg.Len())
+	}
+}
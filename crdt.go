@@ -0,0 +1,297 @@
+package goreg
+
+import (
+	"cmp"
+	"fmt"
+	"iter"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Timestamp is a hybrid logical clock timestamp. Timestamps are totally ordered by wall time,
+// then logical counter, then node name.
+type Timestamp struct {
+	// Wall is the wall clock time in Unix nanoseconds.
+	Wall int64 `json:"wall"`
+
+	// Logical orders events with the same wall time.
+	Logical uint32 `json:"logical"`
+
+	// Node is the name of the node that created the timestamp.
+	Node string `json:"node"`
+}
+
+// Compare returns -1 if t is before u, 1 if t is after u and 0 if they are equal.
+func (t Timestamp) Compare(u Timestamp) int {
+	return cmp.Or(cmp.Compare(t.Wall, u.Wall), cmp.Compare(t.Logical, u.Logical), strings.Compare(t.Node, u.Node))
+}
+
+// String returns a string representation of the timestamp.
+func (t Timestamp) String() string {
+	return fmt.Sprintf("%d.%d@%s", t.Wall, t.Logical, t.Node)
+}
+
+// HLC is a hybrid logical clock. Its timestamps follow the wall clock, but never go backwards
+// and always come after every timestamp it has observed, so they respect causality across nodes.
+type HLC struct {
+	node string
+	now  func() time.Time
+
+	mu   sync.Mutex
+	last Timestamp
+}
+
+// NewHLC creates a new [HLC] for the node. If now is nil, [time.Now] is used.
+func NewHLC(node string, now func() time.Time) *HLC {
+	if now == nil {
+		now = time.Now
+	}
+	return &HLC{node: node, now: now, last: Timestamp{Node: node}}
+}
+
+// Now returns a new timestamp after every timestamp returned or observed so far.
+func (c *HLC) Now() Timestamp {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	wall := c.now().UnixNano()
+	if wall > c.last.Wall {
+		c.last = Timestamp{Wall: wall, Node: c.node}
+	} else {
+		c.last.Logical++
+	}
+	return c.last
+}
+
+// Observe advances the clock past a timestamp received from another node.
+func (c *HLC) Observe(t Timestamp) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	wall := max(c.now().UnixNano(), c.last.Wall, t.Wall)
+	switch {
+	case wall == c.last.Wall && wall == t.Wall:
+		c.last.Logical = max(c.last.Logical, t.Logical) + 1
+	case wall == c.last.Wall:
+		c.last.Logical++
+	case wall == t.Wall:
+		c.last.Logical = t.Logical + 1
+	default:
+		c.last.Logical = 0
+	}
+	c.last.Wall = wall
+}
+
+// LWWEntry is the state of an ID in an [LWWRegistry].
+type LWWEntry[T any] struct {
+	ID      string    `json:"id"`
+	Value   T         `json:"value,omitempty"`
+	Time    Timestamp `json:"time"`
+	Deleted bool      `json:"deleted,omitempty"`
+}
+
+// LWWDelta is a set of changes exported by [LWWRegistry.Delta].
+type LWWDelta[T any] struct {
+	// Seq is the local sequence number of the exporting registry at the time of the export.
+	// Pass it to the next call to Delta to only get what changed since.
+	Seq uint64 `json:"seq"`
+
+	// Entries are the changed entries, sorted by ID.
+	Entries []LWWEntry[T] `json:"entries"`
+}
+
+// LWWOptions configures an [LWWRegistry].
+type LWWOptions struct {
+	// Now returns the current time. Defaults to [time.Now]. Tests can use it to simulate clocks.
+	Now func() time.Time
+}
+
+type lwwState[T any] struct {
+	LWWEntry[T]
+	seq uint64
+}
+
+// LWWRegistry is a conflict-free replicated registry. It is a last-writer-wins map:
+// every ID carries the timestamp of its last write from a hybrid logical clock, and the write with the latest
+// timestamp wins, no matter the order in which replicas exchange their state.
+// Unregistered IDs are kept as tombstones, so that removals propagate too.
+//
+// Replicas exchange state with [LWWRegistry.Merge], or incrementally with [LWWRegistry.Delta] and [LWWRegistry.ApplyDelta].
+type LWWRegistry[T any] struct {
+	clock *HLC
+
+	mu      sync.RWMutex
+	entries map[string]*lwwState[T]
+	live    int
+	seq     uint64
+}
+
+// NewLWWRegistry creates a new [LWWRegistry] for the node. Every replica must have a unique node name.
+//
+// A nil opts is equivalent to a zero [LWWOptions].
+func NewLWWRegistry[T any](node string, opts *LWWOptions) *LWWRegistry[T] {
+	var o LWWOptions
+	if opts != nil {
+		o = *opts
+	}
+	return &LWWRegistry[T]{clock: NewHLC(node, o.Now), entries: make(map[string]*lwwState[T])}
+}
+
+// Register registers an object under the ID.
+func (r *LWWRegistry[T]) Register(id string, obj T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.set(LWWEntry[T]{ID: id, Value: obj, Time: r.clock.Now()})
+}
+
+// Unregister unregisters an object under the ID, leaving a tombstone.
+func (r *LWWRegistry[T]) Unregister(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.set(LWWEntry[T]{ID: id, Time: r.clock.Now(), Deleted: true})
+}
+
+// Get returns the object under the ID.
+func (r *LWWRegistry[T]) Get(id string) (obj T, ok bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	e, ok := r.entries[id]
+	if !ok || e.Deleted {
+		return obj, false
+	}
+	return e.Value, true
+}
+
+// MustGet returns the object under the ID and logs error if not found.
+func (r *LWWRegistry[T]) MustGet(id string) T {
+	obj, ok := r.Get(id)
+	if !ok {
+		slog.Error("*goreg.LWWRegistry: object not found", "id", id)
+	}
+	return obj
+}
+
+// Len returns the number of items in the registry.
+func (r *LWWRegistry[T]) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.live
+}
+
+// Reset wipes the registry, leaving a tombstone for every ID.
+func (r *LWWRegistry[T]) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := r.clock.Now()
+	for id, e := range r.entries {
+		if !e.Deleted {
+			r.set(LWWEntry[T]{ID: id, Time: t, Deleted: true})
+		}
+	}
+}
+
+// Iter returns an iterator over key-value pairs, sorted by ID. See the [iter] package documentation for more details.
+func (r *LWWRegistry[T]) Iter() iter.Seq2[string, T] {
+	return func(yield func(string, T) bool) {
+		for _, e := range r.snapshot() {
+			if !yield(e.Key, e.Value) {
+				return
+			}
+		}
+	}
+}
+
+// snapshot returns the live entries sorted by ID.
+func (r *LWWRegistry[T]) snapshot() []Entry[T] {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := make([]Entry[T], 0, r.live)
+	for _, id := range slices.Sorted(maps.Keys(r.entries)) {
+		if e := r.entries[id]; !e.Deleted {
+			entries = append(entries, Entry[T]{Key: id, Value: e.Value})
+		}
+	}
+	return entries
+}
+
+// String returns a string representation of the registry.
+func (r *LWWRegistry[T]) String() string {
+	return fmt.Sprintf("%v", r.snapshot())
+}
+
+// Delta returns the entries, including tombstones, that changed on this replica after the local sequence number since,
+// whether by a local write or by merging. A since of 0 exports the whole state.
+func (r *LWWRegistry[T]) Delta(since uint64) LWWDelta[T] {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	d := LWWDelta[T]{Seq: r.seq, Entries: []LWWEntry[T]{}}
+	for _, id := range slices.Sorted(maps.Keys(r.entries)) {
+		if e := r.entries[id]; e.seq > since {
+			d.Entries = append(d.Entries, e.LWWEntry)
+		}
+	}
+	return d
+}
+
+// ApplyDelta merges a delta exported by another replica into this one.
+// Applying the same delta more than once, or deltas in any order, gives the same result.
+func (r *LWWRegistry[T]) ApplyDelta(d LWWDelta[T]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range d.Entries {
+		r.clock.Observe(e.Time)
+		if cur, ok := r.entries[e.ID]; ok && cur.Time.Compare(e.Time) >= 0 {
+			continue
+		}
+		r.set(e)
+	}
+}
+
+// Merge merges the whole state of other into this replica.
+func (r *LWWRegistry[T]) Merge(other *LWWRegistry[T]) {
+	if other == r {
+		return
+	}
+	r.ApplyDelta(other.Delta(0))
+}
+
+// PurgeTombstones removes the tombstones older than before and returns how many were removed.
+// It is only safe once every replica has seen the removals, or the removed IDs may come back on the next merge.
+func (r *LWWRegistry[T]) PurgeTombstones(before Timestamp) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for id, e := range r.entries {
+		if e.Deleted && e.Time.Compare(before) < 0 {
+			delete(r.entries, id)
+			n++
+		}
+	}
+	return n
+}
+
+// set stores e, which must be newer than the current state of its ID.
+func (r *LWWRegistry[T]) set(e LWWEntry[T]) {
+	if cur, ok := r.entries[e.ID]; ok && !cur.Deleted {
+		r.live--
+	}
+	if !e.Deleted {
+		r.live++
+	} else {
+		var zero T
+		e.Value = zero
+	}
+
+	r.seq++
+	r.entries[e.ID] = &lwwState[T]{LWWEntry: e, seq: r.seq}
+}
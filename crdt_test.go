@@ -0,0 +1,200 @@
+package goreg_test
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/goreg"
+)
+
+// fakeClock is a manually advanced clock.
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.t
+}
+
+func newReplica(node string, clock *fakeClock) *goreg.LWWRegistry[int] {
+	return goreg.NewLWWRegistry[int](node, &goreg.LWWOptions{Now: clock.Now})
+}
+
+func TestLWWRegistry(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	reg := newReplica("a", clock)
+
+	reg.Register("kozmeker", 69)
+	reg.Register("kajsmentke", 42)
+	reg.Register("kozmeker", 70)
+	reg.Unregister("kajsmentke")
+
+	if val, ok := reg.Get("kozmeker"); !ok || val != 70 {
+		t.Errorf("expected 70, got %v", val)
+	}
+	if _, ok := reg.Get("kajsmentke"); ok {
+		t.Error("expected key kajsmentke to be not found")
+	}
+	if reg.Len() != 1 {
+		t.Errorf("expected length 1, got %d", reg.Len())
+	}
+	if expected := "[{kozmeker 70}]"; reg.String() != expected {
+		t.Errorf("expected %s, got %s", expected, reg)
+	}
+
+	reg.Reset()
+	if reg.Len() != 0 {
+		t.Errorf("expected length 0, got %d", reg.Len())
+	}
+}
+
+func TestLWWRegistry_Merge(t *testing.T) {
+	clockA := &fakeClock{t: time.Unix(1000, 0)}
+	clockB := &fakeClock{t: time.Unix(1000, 0)}
+	a := newReplica("a", clockA)
+	b := newReplica("b", clockB)
+
+	a.Register("kozmeker", 1)
+	clockB.t = clockB.t.Add(time.Second)
+	b.Register("kozmeker", 2) // later write wins
+	b.Register("kajsmentke", 42)
+	a.Register("kocurkovo", 3)
+
+	a.Merge(b)
+	b.Merge(a)
+
+	for _, reg := range []*goreg.LWWRegistry[int]{a, b} {
+		if val, _ := reg.Get("kozmeker"); val != 2 {
+			t.Errorf("expected 2, got %v", val)
+		}
+		if reg.Len() != 3 {
+			t.Errorf("expected length 3, got %d", reg.Len())
+		}
+	}
+
+	// The removal propagates through the tombstone.
+	clockA.t = clockA.t.Add(2 * time.Second)
+	a.Unregister("kajsmentke")
+	b.Merge(a)
+	if _, ok := b.Get("kajsmentke"); ok {
+		t.Error("expected key kajsmentke to be removed by the merge")
+	}
+}
+
+func TestLWWRegistry_ClockSkew(t *testing.T) {
+	clockA := &fakeClock{t: time.Unix(2000, 0)}
+	clockB := &fakeClock{t: time.Unix(1000, 0)} // far behind
+	a := newReplica("a", clockA)
+	b := newReplica("b", clockB)
+
+	a.Register("kozmeker", 1)
+	b.Merge(a)
+
+	// A write made after seeing a's write wins, even though b's wall clock is behind.
+	b.Register("kozmeker", 2)
+	a.Merge(b)
+	if val, _ := a.Get("kozmeker"); val != 2 {
+		t.Errorf("expected 2, got %v", val)
+	}
+}
+
+func TestLWWRegistry_Delta(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	a := newReplica("a", clock)
+	b := newReplica("b", clock)
+
+	a.Register("kozmeker", 69)
+	a.Register("kajsmentke", 42)
+	d := a.Delta(0)
+	if len(d.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(d.Entries))
+	}
+	b.ApplyDelta(d)
+
+	a.Unregister("kozmeker")
+	d = a.Delta(d.Seq)
+	if len(d.Entries) != 1 || d.Entries[0].ID != "kozmeker" || !d.Entries[0].Deleted {
+		t.Fatalf("expected only the kozmeker tombstone, got %+v", d.Entries)
+	}
+	b.ApplyDelta(d)
+	b.ApplyDelta(d) // idempotent
+
+	if !goreg.Equal[int](a, b) {
+		t.Errorf("expected %s, got %s", a, b)
+	}
+}
+
+func TestLWWRegistry_PurgeTombstones(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	reg := newReplica("a", clock)
+	reg.Register("kozmeker", 69)
+	reg.Unregister("kozmeker")
+
+	if n := reg.PurgeTombstones(goreg.Timestamp{Wall: time.Unix(999, 0).UnixNano()}); n != 0 {
+		t.Errorf("expected 0 tombstones purged, got %d", n)
+	}
+	if n := reg.PurgeTombstones(goreg.Timestamp{Wall: time.Unix(1001, 0).UnixNano()}); n != 1 {
+		t.Errorf("expected 1 tombstone purged, got %d", n)
+	}
+	if d := reg.Delta(0); len(d.Entries) != 0 {
+		t.Errorf("expected empty state, got %+v", d.Entries)
+	}
+}
+
+// TestLWWRegistry_Convergence drives simulated replicas with random operations and random merges
+// and checks that they all converge once every replica has seen every other one, whatever the merge order.
+func TestLWWRegistry_Convergence(t *testing.T) {
+	for seed := range uint64(20) {
+		t.Run(fmt.Sprint(seed), func(t *testing.T) {
+			rng := rand.New(rand.NewPCG(seed, 0))
+
+			const n = 4
+			clocks := make([]*fakeClock, n)
+			replicas := make([]*goreg.LWWRegistry[int], n)
+			for i := range n {
+				// Clocks start skewed by up to a second.
+				clocks[i] = &fakeClock{t: time.Unix(1000, rng.Int64N(int64(time.Second)))}
+				replicas[i] = newReplica(fmt.Sprint("node", i), clocks[i])
+			}
+
+			for range 200 {
+				i := rng.IntN(n)
+				clocks[i].t = clocks[i].t.Add(time.Duration(rng.IntN(3)) * time.Millisecond)
+				id := fmt.Sprint("id", rng.IntN(10))
+				switch rng.IntN(10) {
+				case 0:
+					replicas[i].Unregister(id)
+				case 1:
+					replicas[rng.IntN(n)].Merge(replicas[i])
+				case 2:
+					j := rng.IntN(n)
+					replicas[j].ApplyDelta(replicas[i].Delta(uint64(rng.IntN(50))))
+				default:
+					replicas[i].Register(id, rng.IntN(100))
+				}
+			}
+
+			// Full exchange in a random order.
+			for _, i := range rng.Perm(n) {
+				for _, j := range rng.Perm(n) {
+					replicas[j].Merge(replicas[i])
+				}
+			}
+			for _, i := range rng.Perm(n) {
+				for _, j := range rng.Perm(n) {
+					replicas[j].Merge(replicas[i])
+				}
+			}
+
+			expected := replicas[0].Delta(0).Entries
+			for i, reg := range replicas[1:] {
+				if got := reg.Delta(0).Entries; !reflect.DeepEqual(got, expected) {
+					t.Errorf("replica %d diverged: expected %+v, got %+v", i+1, expected, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package goreg
+
+import (
+	"encoding"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+)
+
+// CSVCodec is a [Codec] that encodes entries as CSV with a header row.
+// The first column holds the ID and the remaining columns hold the value.
+//
+// By default, values are mapped by reflection: if T is a struct, every exported field becomes a column
+// named after the field or its `csv:"name"` tag (a tag of "-" skips the field); otherwise the value
+// is stored in a single "value" column. Fields must be strings, booleans, numbers or implement
+// [encoding.TextMarshaler] and [encoding.TextUnmarshaler]. Embedded structs are not flattened.
+//
+// Set Columns, Marshal and Unmarshal to map values to columns by hand.
+type CSVCodec[T any] struct {
+	// IDColumn is the header of the ID column. Defaults to "id".
+	IDColumn string
+
+	// Columns are the headers of the value columns. Required when Marshal and Unmarshal are set.
+	Columns []string
+
+	// Marshal converts a value into fields in the order of Columns.
+	Marshal func(obj T) ([]string, error)
+
+	// Unmarshal converts fields in the order of Columns into a value.
+	Unmarshal func(fields []string) (T, error)
+
+	// Comma is the field delimiter. Defaults to ','.
+	Comma rune
+}
+
+type csvField struct {
+	name  string
+	index int
+}
+
+func (c CSVCodec[T]) idColumn() string {
+	if c.IDColumn == "" {
+		return "id"
+	}
+	return c.IDColumn
+}
+
+// mapping returns the value columns along with functions that convert between values and fields.
+func (c CSVCodec[T]) mapping() (columns []string, marshal func(T) ([]string, error), unmarshal func([]string) (T, error), err error) {
+	if c.Marshal != nil || c.Unmarshal != nil {
+		if c.Marshal == nil || c.Unmarshal == nil || len(c.Columns) == 0 {
+			return nil, nil, nil, errors.New("goreg: CSVCodec requires Columns, Marshal and Unmarshal to be set together")
+		}
+		return c.Columns, c.Marshal, c.Unmarshal, nil
+	}
+
+	typ := reflect.TypeFor[T]()
+	if typ.Kind() != reflect.Struct || typ.Implements(textMarshalerType) {
+		marshal = func(obj T) ([]string, error) {
+			s, err := formatCSVValue(reflect.ValueOf(&obj).Elem())
+			return []string{s}, err
+		}
+		unmarshal = func(fields []string) (obj T, err error) {
+			err = parseCSVValue(fields[0], reflect.ValueOf(&obj).Elem())
+			return
+		}
+		return []string{"value"}, marshal, unmarshal, nil
+	}
+
+	var fields []csvField
+	for i := range typ.NumField() {
+		f := typ.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("csv"); ok {
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+		fields = append(fields, csvField{name: name, index: i})
+		columns = append(columns, name)
+	}
+
+	marshal = func(obj T) ([]string, error) {
+		v := reflect.ValueOf(obj)
+		record := make([]string, len(fields))
+		for i, f := range fields {
+			s, err := formatCSVValue(v.Field(f.index))
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", f.name, err)
+			}
+			record[i] = s
+		}
+		return record, nil
+	}
+	unmarshal = func(record []string) (obj T, err error) {
+		v := reflect.ValueOf(&obj).Elem()
+		for i, f := range fields {
+			if err := parseCSVValue(record[i], v.Field(f.index)); err != nil {
+				return obj, fmt.Errorf("field %s: %w", f.name, err)
+			}
+		}
+		return obj, nil
+	}
+
+	return columns, marshal, unmarshal, nil
+}
+
+// Encode writes the entries to w.
+func (c CSVCodec[T]) Encode(w io.Writer, entries []Entry[T]) error {
+	columns, marshal, _, err := c.mapping()
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	if c.Comma != 0 {
+		cw.Comma = c.Comma
+	}
+
+	if err := cw.Write(append([]string{c.idColumn()}, columns...)); err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		fields, err := marshal(e.Value)
+		if err != nil {
+			return fmt.Errorf("goreg: encoding %q: %w", e.Key, err)
+		}
+		if len(fields) != len(columns) {
+			return fmt.Errorf("goreg: encoding %q: expected %d fields, got %d", e.Key, len(columns), len(fields))
+		}
+		if err := cw.Write(append([]string{e.Key}, fields...)); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// Decode reads entries from r. Columns are matched by their header, so they may appear in any order.
+// Columns that are not part of the mapping are ignored and missing columns are left at their zero value.
+func (c CSVCodec[T]) Decode(r io.Reader) ([]Entry[T], error) {
+	columns, _, unmarshal, err := c.mapping()
+	if err != nil {
+		return nil, err
+	}
+
+	cr := csv.NewReader(r)
+	if c.Comma != 0 {
+		cr.Comma = c.Comma
+	}
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	idCol := slices.Index(header, c.idColumn())
+	if idCol < 0 {
+		return nil, fmt.Errorf("goreg: missing %q column", c.idColumn())
+	}
+
+	// cols[i] is the index of the i-th value column in the file, or -1 if it is missing.
+	cols := make([]int, len(columns))
+	for i, name := range columns {
+		cols[i] = slices.Index(header, name)
+	}
+
+	var entries []Entry[T]
+	fields := make([]string, len(columns))
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for i, col := range cols {
+			if col < 0 {
+				fields[i] = ""
+			} else {
+				fields[i] = record[col]
+			}
+		}
+
+		id := record[idCol]
+		obj, err := unmarshal(fields)
+		if err != nil {
+			line, _ := cr.FieldPos(idCol)
+			return nil, fmt.Errorf("goreg: decoding %q on line %d: %w", id, line, err)
+		}
+		entries = append(entries, Entry[T]{Key: id, Value: obj})
+	}
+}
+
+var (
+	textMarshalerType   = reflect.TypeFor[encoding.TextMarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+func formatCSVValue(v reflect.Value) (string, error) {
+	if v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	default:
+		return "", fmt.Errorf("unsupported type %s", v.Type())
+	}
+}
+
+func parseCSVValue(s string, v reflect.Value) error {
+	if reflect.PointerTo(v.Type()).Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		if s == "" {
+			v.SetBool(false)
+			return nil
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if s == "" {
+			v.SetInt(0)
+			return nil
+		}
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if s == "" {
+			v.SetUint(0)
+			return nil
+		}
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		if s == "" {
+			v.SetFloat(0)
+			return nil
+		}
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package goreg_test
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/MatusOllah/goreg"
+)
+
+type csvLevel struct {
+	Name   string  `csv:"name"`
+	Stars  int     `csv:"stars"`
+	Time   float64 `csv:"time"`
+	Secret bool
+	Notes  string `csv:"-"`
+}
+
+func TestCSVCodec(t *testing.T) {
+	testCodecRoundTrip(t, goreg.CSVCodec[int]{})
+	testCodecRoundTrip(t, goreg.CSVCodec[int]{IDColumn: "ID", Comma: ';'})
+}
+
+func TestCSVCodec_Struct(t *testing.T) {
+	reg := goreg.NewOrderedRegistry[csvLevel]()
+	reg.Register("level2", csvLevel{Name: "Level 2, the sequel", Stars: 3, Time: 12.5})
+	reg.Register("level1", csvLevel{Name: "Level 1", Stars: 1, Secret: true, Notes: "ignored"})
+
+	var bf bytes.Buffer
+	if err := goreg.Encode(reg, goreg.CSVCodec[csvLevel]{}, &bf); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+
+	expected := "id,name,stars,time,Secret\nlevel2,\"Level 2, the sequel\",3,12.5,false\nlevel1,Level 1,1,0,true\n"
+	if bf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, bf.String())
+	}
+
+	newReg := goreg.NewOrderedRegistry[csvLevel]()
+	if err := goreg.Decode(newReg, goreg.CSVCodec[csvLevel]{}, &bf); err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	if val, ok := newReg.GetIndex(1); !ok || val.Name != "Level 1" || !val.Secret || val.Notes != "" {
+		t.Errorf("unexpected value %+v", val)
+	}
+}
+
+func TestCSVCodec_DecodeColumnOrder(t *testing.T) {
+	data := "stars,comment,id,name\n3,lol,level2,Level 2\n,,level1,Level 1\n"
+
+	entries, err := goreg.CSVCodec[csvLevel]{}.Decode(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+
+	expected := []goreg.Entry[csvLevel]{
+		{Key: "level2", Value: csvLevel{Name: "Level 2", Stars: 3}},
+		{Key: "level1", Value: csvLevel{Name: "Level 1"}},
+	}
+	if len(entries) != len(expected) || entries[0] != expected[0] || entries[1] != expected[1] {
+		t.Errorf("expected %v, got %v", expected, entries)
+	}
+}
+
+func TestCSVCodec_DecodeInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"Empty", ""},
+		{"Missing ID column", "name,stars\nLevel 1,1\n"},
+		{"Invalid number", "id,stars\nlevel1,many\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := (goreg.CSVCodec[csvLevel]{}).Decode(strings.NewReader(test.data)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestCSVCodec_Mapping(t *testing.T) {
+	codec := goreg.CSVCodec[[2]int]{
+		Columns: []string{"x", "y"},
+		Marshal: func(obj [2]int) ([]string, error) {
+			return []string{strconv.Itoa(obj[0]), strconv.Itoa(obj[1])}, nil
+		},
+		Unmarshal: func(fields []string) (obj [2]int, err error) {
+			x, err1 := strconv.Atoi(fields[0])
+			y, err2 := strconv.Atoi(fields[1])
+			return [2]int{x, y}, errors.Join(err1, err2)
+		},
+	}
+
+	reg := goreg.NewOrderedRegistry[[2]int]()
+	reg.Register("spawn", [2]int{4, 2})
+
+	var bf bytes.Buffer
+	if err := goreg.Encode(reg, codec, &bf); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	if expected := "id,x,y\nspawn,4,2\n"; bf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, bf.String())
+	}
+
+	entries, err := codec.Decode(strings.NewReader("y,id,x\n2,spawn,4\n"))
+	if err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Value != [2]int{4, 2} {
+		t.Errorf("unexpected entries %v", entries)
+	}
+
+	if err := goreg.Encode(reg, goreg.CSVCodec[[2]int]{Marshal: codec.Marshal}, &bf); err == nil {
+		t.Error("expected error for incomplete mapping")
+	}
+}
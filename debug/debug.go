@@ -0,0 +1,269 @@
+// Package debug serves introspection pages for the registries published with [goreg.Publish].
+//
+// It is typically only imported for the side effect of registering its HTTP handler,
+// like [net/http/pprof]:
+//
+//	import _ "github.com/MatusOllah/goreg/debug"
+//
+// The handler lists every published registry at /debug/goreg/, with its type, number of entries
+// and lock-contention statistics, and the entries of a registry at /debug/goreg/{name}.
+// Entry pages take the query parameters q (a case-insensitive substring of the ID or JSON value), offset and limit.
+// Add format=json to any page for JSON instead of HTML.
+package debug
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/MatusOllah/goreg"
+)
+
+func init() {
+	http.Handle("/debug/goreg/", http.StripPrefix("/debug/goreg", Handler()))
+}
+
+const (
+	defaultLimit = 50
+	maxLimit     = 1000
+)
+
+// Registry describes a published registry.
+type Registry struct {
+	Name      string           `json:"name"`
+	Type      string           `json:"type"`
+	ValueType string           `json:"valueType"`
+	Len       int              `json:"len"`
+	LockStats *goreg.LockStats `json:"lockStats,omitempty"`
+}
+
+// Entry is an entry of a registry, with its value encoded as JSON.
+type Entry struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+// EntryPage is a page of the entries of a registry.
+type EntryPage struct {
+	Registry
+
+	// Query is the search query.
+	Query string `json:"query,omitempty"`
+
+	// Matched is the number of entries matching the query.
+	Matched int `json:"matched"`
+
+	Offset  int     `json:"offset"`
+	Limit   int     `json:"limit"`
+	Entries []Entry `json:"entries"`
+}
+
+// Handler returns the introspection handler. It expects to be mounted with [http.StripPrefix],
+// so that the index is at / and the registries are below it.
+func Handler() http.Handler {
+	return http.HandlerFunc(serve)
+}
+
+func serve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name, err := url.PathUnescape(strings.TrimPrefix(r.URL.EscapedPath(), "/"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if name == "" {
+		serveIndex(w, r)
+		return
+	}
+
+	reg, ok := goreg.Lookup(name)
+	if !ok {
+		http.Error(w, fmt.Sprintf("registry %q not found", name), http.StatusNotFound)
+		return
+	}
+	serveRegistry(w, r, reg)
+}
+
+func serveIndex(w http.ResponseWriter, r *http.Request) {
+	var regs []Registry
+	for _, reg := range goreg.Published() {
+		regs = append(regs, describe(reg))
+	}
+	if regs == nil {
+		regs = []Registry{}
+	}
+	render(w, r, indexTemplate, regs)
+}
+
+func serveRegistry(w http.ResponseWriter, r *http.Request, reg goreg.NamedRegistry) {
+	q := r.URL.Query()
+	page := EntryPage{Query: q.Get("q"), Limit: defaultLimit, Entries: []Entry{}}
+	if s := q.Get("offset"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			http.Error(w, fmt.Sprintf("invalid offset %q", s), http.StatusBadRequest)
+			return
+		}
+		page.Offset = n
+	}
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			http.Error(w, fmt.Sprintf("invalid limit %q", s), http.StatusBadRequest)
+			return
+		}
+		page.Limit = min(n, maxLimit)
+	}
+
+	ids := reg.IDs()
+	page.Registry = describe(reg)
+	page.Len = len(ids)
+
+	// Without a query, only the values on the page are needed.
+	if page.Query == "" {
+		page.Matched = len(ids)
+		start := min(page.Offset, len(ids))
+		for _, id := range ids[start : start+min(page.Limit, len(ids)-start)] {
+			if obj, ok := reg.Get(id); ok {
+				page.Entries = append(page.Entries, Entry{Key: id, Value: marshalValue(obj)})
+			}
+		}
+		render(w, r, registryTemplate, page)
+		return
+	}
+
+	query := strings.ToLower(page.Query)
+	for _, id := range ids {
+		obj, ok := reg.Get(id)
+		if !ok {
+			continue
+		}
+		value := marshalValue(obj)
+		if !strings.Contains(strings.ToLower(id), query) && !strings.Contains(strings.ToLower(string(value)), query) {
+			continue
+		}
+		if page.Matched >= page.Offset && len(page.Entries) < page.Limit {
+			page.Entries = append(page.Entries, Entry{Key: id, Value: value})
+		}
+		page.Matched++
+	}
+
+	render(w, r, registryTemplate, page)
+}
+
+// marshalValue encodes obj as JSON, falling back to its string form if it can't be encoded.
+func marshalValue(obj any) json.RawMessage {
+	value, err := json.Marshal(obj)
+	if err != nil {
+		value, _ = json.Marshal(fmt.Sprintf("%v", obj))
+	}
+	return value
+}
+
+func describe(reg goreg.NamedRegistry) Registry {
+	d := Registry{Name: reg.Name(), Type: reg.Type(), ValueType: reg.ValueType(), Len: reg.Len()}
+	if stats, ok := reg.LockStats(); ok {
+		d.LockStats = &stats
+	}
+	return d
+}
+
+func render(w http.ResponseWriter, r *http.Request, tmpl *template.Template, data any) {
+	if r.URL.Query().Get("format") == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		enc.Encode(data)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+var funcs = template.FuncMap{
+	"pathEscape": url.PathEscape,
+	"add": func(a, b int) int {
+		return a + b
+	},
+	"prev": func(offset, limit int) int {
+		return max(offset-limit, 0)
+	},
+}
+
+var indexTemplate = template.Must(template.New("index").Funcs(funcs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>/debug/goreg/</title>
+<style>
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.5em; text-align: left; }
+</style>
+</head>
+<body>
+<h1>/debug/goreg/</h1>
+{{if .}}
+<table>
+<tr><th>Name</th><th>Type</th><th>Value type</th><th>Entries</th><th>Lock acquisitions</th><th>Contended</th><th>Wait</th></tr>
+{{range .}}
+<tr>
+<td><a href="{{pathEscape .Name}}">{{.Name}}</a></td>
+<td>{{.Type}}</td>
+<td>{{.ValueType}}</td>
+<td>{{.Len}}</td>
+{{with .LockStats}}<td>{{.Acquisitions}}</td><td>{{.Contended}}</td><td>{{.Wait}}</td>{{else}}<td colspan="3">n/a</td>{{end}}
+</tr>
+{{end}}
+</table>
+{{else}}
+<p>No registries published.</p>
+{{end}}
+<p><a href="?format=json">JSON</a></p>
+</body>
+</html>
+`))
+
+var registryTemplate = template.Must(template.New("registry").Funcs(funcs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>/debug/goreg/{{.Name}}</title>
+<style>
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.5em; text-align: left; vertical-align: top; }
+td pre { margin: 0; }
+</style>
+</head>
+<body>
+<h1><a href="./">/debug/goreg/</a>{{.Name}}</h1>
+<p>{{.Type}}, {{.Len}} entries{{with .LockStats}}, {{.Acquisitions}} lock acquisitions, {{.Contended}} contended, {{.Wait}} waiting{{end}}</p>
+<form>
+<input name="q" value="{{.Query}}" placeholder="Search IDs and values">
+<input type="hidden" name="limit" value="{{.Limit}}">
+<button>Search</button>
+</form>
+<p>{{.Matched}} matching, showing {{len .Entries}} from {{.Offset}}</p>
+<table>
+<tr><th>ID</th><th>Value</th></tr>
+{{range .Entries}}
+<tr><td>{{.Key}}</td><td><pre>{{printf "%s" .Value}}</pre></td></tr>
+{{end}}
+</table>
+<p>
+{{if gt .Offset 0}}<a href="?q={{.Query}}&amp;offset={{prev .Offset .Limit}}&amp;limit={{.Limit}}">Previous</a>{{end}}
+{{if lt (add .Offset .Limit) .Matched}}<a href="?q={{.Query}}&amp;offset={{add .Offset .Limit}}&amp;limit={{.Limit}}">Next</a>{{end}}
+<a href="?q={{.Query}}&amp;offset={{.Offset}}&amp;limit={{.Limit}}&amp;format=json">JSON</a>
+</p>
+</body>
+</html>
+`))
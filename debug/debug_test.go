@@ -0,0 +1,93 @@
+package debug_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MatusOllah/goreg"
+	"github.com/MatusOllah/goreg/debug"
+)
+
+func publish(t *testing.T, name string, reg goreg.Registry[int]) {
+	t.Helper()
+	if err := goreg.Publish(name, reg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { goreg.Unpublish(name) })
+}
+
+func get(t *testing.T, target string, v any) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	http.DefaultServeMux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s: expected 200, got %d %s", target, rec.Code, rec.Body)
+	}
+	if v != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rec.Body.String()
+}
+
+func TestIndex(t *testing.T) {
+	reg := goreg.NewStandardRegistry[int]()
+	reg.Register("kozmeker", 69)
+	publish(t, "items", reg)
+	publish(t, "feed", goreg.NewFeedRegistry[int](goreg.NewStandardRegistry[int](), nil))
+
+	var regs []debug.Registry
+	get(t, "/debug/goreg/?format=json", &regs)
+	if len(regs) != 2 || regs[0].Name != "feed" || regs[1].Name != "items" {
+		t.Fatalf("expected feed and items, got %+v", regs)
+	}
+	if regs[1].Type != "*goreg.StandardRegistry[int]" || regs[1].ValueType != "int" || regs[1].Len != 1 {
+		t.Errorf("unexpected description %+v", regs[1])
+	}
+	if regs[1].LockStats == nil || regs[0].LockStats != nil {
+		t.Errorf("expected lock stats only for items, got %v and %v", regs[0].LockStats, regs[1].LockStats)
+	}
+
+	html := get(t, "/debug/goreg/", nil)
+	if !strings.Contains(html, `<a href="items">items</a>`) {
+		t.Errorf("expected a link to items, got %s", html)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	reg := goreg.NewStandardRegistry[int]()
+	for i := range 30 {
+		reg.Register(fmt.Sprintf("item%02d", i), i)
+	}
+	publish(t, "mod/items", reg)
+
+	var page debug.EntryPage
+	get(t, "/debug/goreg/mod%2Fitems?format=json&offset=10&limit=5", &page)
+	if page.Len != 30 || page.Matched != 30 || len(page.Entries) != 5 || page.Entries[0].Key != "item10" {
+		t.Errorf("unexpected page %+v", page)
+	}
+
+	page = debug.EntryPage{}
+	get(t, "/debug/goreg/mod%2Fitems?format=json&q=ITEM1", &page)
+	if page.Matched != 10 || len(page.Entries) != 10 || string(page.Entries[9].Value) != "19" {
+		t.Errorf("unexpected search result %+v", page)
+	}
+
+	html := get(t, "/debug/goreg/mod%2Fitems?q=item2&limit=5", nil)
+	for _, want := range []string{"item20", "10 matching", `offset=5`} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %q in %s", want, html)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	http.DefaultServeMux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/goreg/nope", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rec.Code)
+	}
+}
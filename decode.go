@@ -0,0 +1,187 @@
+package goreg
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrDuplicateID is returned in [LoadStrict] mode when the input contains an ID more than once.
+	ErrDuplicateID = errors.New("duplicate ID")
+
+	// ErrEmptyID is returned in [LoadStrict] mode when the input contains an empty ID.
+	ErrEmptyID = errors.New("empty ID")
+)
+
+// LoadMode controls how decoded entries are loaded into a registry.
+type LoadMode int
+
+const (
+	// LoadReplace replaces the contents of the registry with the decoded entries. This is the default.
+	LoadReplace LoadMode = iota
+
+	// LoadMerge registers the decoded entries on top of the existing contents of the registry.
+	// Conflicts are resolved with [DecodeOptions.Resolve], and the existing object is unregistered
+	// before the resolved one is registered, so ordered registries move it to the end.
+	LoadMerge
+
+	// LoadStrict is like LoadReplace, but rejects duplicate IDs, empty IDs and unknown fields.
+	// Unknown fields are only detected by [JSONCodec], [JSONObjectCodec] and [JSONLCodec].
+	LoadStrict
+)
+
+// DecodeOptions configures [DecodeWith].
+type DecodeOptions[T any] struct {
+	// Mode is the load mode. Defaults to [LoadReplace].
+	Mode LoadMode
+
+	// Resolve is called in [LoadMerge] mode when a decoded ID is already registered and returns the object to keep.
+	// If nil, the decoded object wins.
+	Resolve func(id string, old, new T) T
+
+	// Limits bounds the resources used when decoding. The zero value imposes no limits.
+	Limits DecodeLimits
+}
+
+// DecodeError describes a problem with a specific entry in the input.
+type DecodeError struct {
+	// Index is the index of the entry in the input, or -1 if the problem is not with a specific entry.
+	Index int
+
+	// Key is the ID of the entry, if known.
+	Key string
+
+	// Offset is the byte offset in the input where the entry starts, or -1 if unknown.
+	Offset int64
+
+	// Err is the underlying error.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *DecodeError) Error() string {
+	var sb strings.Builder
+	sb.WriteString("goreg: ")
+	if e.Index >= 0 {
+		fmt.Fprintf(&sb, "entry %d", e.Index)
+		if e.Key != "" {
+			fmt.Fprintf(&sb, " (%q)", e.Key)
+		}
+		if e.Offset >= 0 {
+			sb.WriteByte(' ')
+		}
+	}
+	if e.Offset >= 0 {
+		fmt.Fprintf(&sb, "at offset %d", e.Offset)
+	}
+	if e.Index >= 0 || e.Offset >= 0 {
+		sb.WriteString(": ")
+	}
+	sb.WriteString(e.Err.Error())
+	return sb.String()
+}
+
+// Unwrap returns the underlying error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// entryStreamer is implemented by codecs that can decode entry by entry and report where every entry starts.
+type entryStreamer[T any] interface {
+	streamEntries(r io.Reader, strict bool, fn func(e Entry[T], off int64) error) error
+}
+
+// isJSONCodec reports whether codec reads JSON, so that [DecodeLimits.MaxDepth] applies.
+func isJSONCodec[T any](codec Codec[T]) bool {
+	switch codec.(type) {
+	case JSONCodec[T], *JSONCodec[T], JSONObjectCodec[T], *JSONObjectCodec[T], JSONLCodec[T], *JSONLCodec[T],
+		VersionedJSONCodec[T], *VersionedJSONCodec[T]:
+		return true
+	}
+	return false
+}
+
+// DecodeWith decodes objects from r using codec and loads them into reg according to opts.
+// The whole input is decoded before reg is modified, so if decoding fails, reg is left untouched.
+// In [LoadMerge] mode, it fails if an existing ID can't be unregistered, for example because reg is read-only,
+// leaving the entries merged up to that point.
+// Problems with specific entries are reported as [*DecodeError]. Input that exceeds opts.Limits
+// is reported as [*LimitError], possibly wrapped in a [*DecodeError].
+//
+// A nil opts is equivalent to a zero [DecodeOptions].
+func DecodeWith[T any](reg Registry[T], codec Codec[T], r io.Reader, opts *DecodeOptions[T]) error {
+	var o DecodeOptions[T]
+	if opts != nil {
+		o = *opts
+	}
+	if o.Mode < LoadReplace || o.Mode > LoadStrict {
+		return fmt.Errorf("goreg: invalid load mode %d", o.Mode)
+	}
+	strict := o.Mode == LoadStrict
+	r = o.Limits.reader(r, isJSONCodec(codec))
+
+	var entries []Entry[T]
+	var seen map[string]struct{}
+	if strict {
+		seen = make(map[string]struct{})
+	}
+	add := func(e Entry[T], off int64) error {
+		if err := o.Limits.checkEntry(len(entries), e.Key); err != nil {
+			return &DecodeError{Index: len(entries), Key: e.Key, Offset: off, Err: err}
+		}
+		if strict {
+			if e.Key == "" {
+				return &DecodeError{Index: len(entries), Offset: off, Err: ErrEmptyID}
+			}
+			if _, ok := seen[e.Key]; ok {
+				return &DecodeError{Index: len(entries), Key: e.Key, Offset: off, Err: ErrDuplicateID}
+			}
+			seen[e.Key] = struct{}{}
+		}
+		entries = append(entries, e)
+		return nil
+	}
+
+	if s, ok := codec.(entryStreamer[T]); ok {
+		if err := s.streamEntries(r, strict, add); err != nil {
+			return err
+		}
+	} else {
+		decoded, err := codec.Decode(r)
+		if err != nil {
+			return err
+		}
+		for _, e := range decoded {
+			if err := add(e, -1); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch o.Mode {
+	case LoadMerge:
+		for _, e := range entries {
+			if old, ok := reg.Get(e.Key); ok {
+				if o.Resolve != nil {
+					e.Value = o.Resolve(e.Key, old, e.Value)
+				}
+				// Registries like OrderedRegistry keep duplicate IDs, so registering on top
+				// would leave the old object in front of the resolved one.
+				reg.Unregister(e.Key)
+				if _, ok := reg.Get(e.Key); ok {
+					return fmt.Errorf("goreg: could not unregister %q", e.Key)
+				}
+			}
+			reg.Register(e.Key, e.Value)
+		}
+	default:
+		reg.Reset()
+		for _, e := range entries {
+			reg.Register(e.Key, e.Value)
+		}
+	}
+
+	return nil
+}
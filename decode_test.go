@@ -0,0 +1,173 @@
+package goreg_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/MatusOllah/goreg"
+)
+
+func TestDecodeWith_Replace(t *testing.T) {
+	reg := goreg.NewStandardRegistry[int]()
+	reg.Register("existing", 1)
+
+	if err := goreg.DecodeWith[int](reg, goreg.JSONCodec[int]{}, strings.NewReader(`{"kajsmentke":42}`), nil); err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+
+	if _, ok := reg.Get("existing"); ok {
+		t.Error("expected key existing to be not found")
+	}
+	if val, ok := reg.Get("kajsmentke"); !ok || val != 42 {
+		t.Errorf("expected 42, got %v", val)
+	}
+}
+
+func TestDecodeWith_Merge(t *testing.T) {
+	reg := goreg.NewStandardRegistry[int]()
+	reg.Register("existing", 1)
+	reg.Register("kajsmentke", 40)
+
+	opts := &goreg.DecodeOptions[int]{
+		Mode: goreg.LoadMerge,
+		Resolve: func(id string, old, new int) int {
+			return old + new
+		},
+	}
+	if err := goreg.DecodeWith[int](reg, goreg.JSONCodec[int]{}, strings.NewReader(`{"kajsmentke":2,"kozmeker":69}`), opts); err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+
+	if val, ok := reg.Get("existing"); !ok || val != 1 {
+		t.Errorf("expected 1, got %v", val)
+	}
+	if val, ok := reg.Get("kajsmentke"); !ok || val != 42 {
+		t.Errorf("expected 42, got %v", val)
+	}
+	if val, ok := reg.Get("kozmeker"); !ok || val != 69 {
+		t.Errorf("expected 69, got %v", val)
+	}
+}
+
+func TestDecodeWith_MergeOrdered(t *testing.T) {
+	reg := goreg.NewOrderedRegistry[int]()
+	reg.Register("kajsmentke", 40)
+	reg.Register("existing", 1)
+
+	opts := &goreg.DecodeOptions[int]{
+		Mode: goreg.LoadMerge,
+		Resolve: func(id string, old, new int) int {
+			return old + new
+		},
+	}
+	if err := goreg.DecodeWith[int](reg, goreg.JSONCodec[int]{}, strings.NewReader(`{"kajsmentke":2,"kozmeker":69}`), opts); err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+
+	if reg.Len() != 3 {
+		t.Errorf("expected length 3, got %d", reg.Len())
+	}
+	if val, ok := reg.Get("kajsmentke"); !ok || val != 42 {
+		t.Errorf("expected 42, got %v", val)
+	}
+	expected := "[{existing 1} {kajsmentke 42} {kozmeker 69}]"
+	if reg.String() != expected {
+		t.Errorf("expected %s, got %s", expected, reg.String())
+	}
+}
+
+func TestDecodeWith_MergeReadOnly(t *testing.T) {
+	src := goreg.NewStandardRegistry[int]()
+	src.Register("kajsmentke", 40)
+	reg, err := goreg.OpenMappedRegistry[int](writeMappedFile(t, src))
+	if err != nil {
+		t.Fatalf("failed to open mapped registry: %v", err)
+	}
+	defer reg.Close()
+
+	opts := &goreg.DecodeOptions[int]{Mode: goreg.LoadMerge}
+	if err := goreg.DecodeWith[int](reg, goreg.JSONCodec[int]{}, strings.NewReader(`{"kajsmentke":42}`), opts); err == nil {
+		t.Error("expected error for a read-only registry")
+	}
+}
+
+func TestDecodeWith_Strict(t *testing.T) {
+	type Level struct {
+		Name string `json:"name"`
+	}
+
+	tests := []struct {
+		name   string
+		codec  goreg.Codec[Level]
+		data   string
+		err    error
+		index  int
+		offset int64
+	}{
+		{"Duplicate ID", goreg.JSONCodec[Level]{}, `{"a":{},"b":{},"a":{}}`, goreg.ErrDuplicateID, 2, 15},
+		{"Empty ID", goreg.JSONCodec[Level]{}, `[{"key":"a","value":{}},{"key":"","value":{}}]`, goreg.ErrEmptyID, 1, 24},
+		{"Unknown value field", goreg.JSONCodec[Level]{}, `{"a":{"name":"A","stars":3}}`, nil, 0, 1},
+		{"Unknown entry field", goreg.JSONCodec[Level]{}, `[{"key":"a","value":{},"extra":1}]`, nil, 0, 1},
+		{"JSON Lines duplicate ID", goreg.JSONLCodec[Level]{}, "{\"key\":\"a\"}\n{\"key\":\"a\"}\n", goreg.ErrDuplicateID, 1, 12},
+		{"CSV duplicate ID", goreg.CSVCodec[Level]{}, "id,name\na,A\na,B\n", goreg.ErrDuplicateID, 1, -1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reg := goreg.NewStandardRegistry[Level]()
+			reg.Register("existing", Level{})
+
+			err := goreg.DecodeWith[Level](reg, test.codec, strings.NewReader(test.data), &goreg.DecodeOptions[Level]{Mode: goreg.LoadStrict})
+
+			var decErr *goreg.DecodeError
+			if !errors.As(err, &decErr) {
+				t.Fatalf("expected *DecodeError, got %v", err)
+			}
+			if test.err != nil && !errors.Is(err, test.err) {
+				t.Errorf("expected %v, got %v", test.err, err)
+			}
+			if decErr.Index != test.index {
+				t.Errorf("expected index %d, got %d", test.index, decErr.Index)
+			}
+			if decErr.Offset != test.offset {
+				t.Errorf("expected offset %d, got %d", test.offset, decErr.Offset)
+			}
+
+			if reg.Len() != 1 {
+				t.Errorf("expected registry to be untouched, got length %d", reg.Len())
+			}
+		})
+	}
+}
+
+func TestDecodeWith_StrictValid(t *testing.T) {
+	reg := goreg.NewOrderedRegistry[int]()
+	data := `{"kozmeker":69,"kajsmentke":42}`
+
+	if err := goreg.DecodeWith[int](reg, goreg.JSONObjectCodec[int]{}, strings.NewReader(data), &goreg.DecodeOptions[int]{Mode: goreg.LoadStrict}); err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+
+	expected := `[{kozmeker 69} {kajsmentke 42}]`
+	if reg.String() != expected {
+		t.Errorf("expected %s, got %s", expected, reg)
+	}
+}
+
+func TestDecodeError_Error(t *testing.T) {
+	tests := []struct {
+		err      *goreg.DecodeError
+		expected string
+	}{
+		{&goreg.DecodeError{Index: 2, Key: "a", Offset: 15, Err: goreg.ErrDuplicateID}, `goreg: entry 2 ("a") at offset 15: duplicate ID`},
+		{&goreg.DecodeError{Index: 1, Offset: -1, Err: goreg.ErrEmptyID}, `goreg: entry 1: empty ID`},
+		{&goreg.DecodeError{Index: -1, Offset: 4, Err: errors.New("oops")}, `goreg: at offset 4: oops`},
+	}
+
+	for _, test := range tests {
+		if s := test.err.Error(); s != test.expected {
+			t.Errorf("expected %s, got %s", test.expected, s)
+		}
+	}
+}
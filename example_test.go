@@ -2,6 +2,7 @@ package goreg_test
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/MatusOllah/goreg"
 )
@@ -202,6 +203,19 @@ func ExampleCollect() {
 	// map[chair:Chair door:Door window:Window]
 }
 
+func ExampleEncode() {
+	type Thing string
+
+	reg := goreg.NewOrderedRegistry[Thing]()
+	reg.Register("door", Thing("Door"))
+	reg.Register("window", Thing("Window"))
+
+	goreg.Encode(reg, goreg.JSONCodec[Thing]{}, os.Stdout)
+
+	// Output:
+	// [{"key":"door","value":"Door"},{"key":"window","value":"Window"}]
+}
+
 func ExampleCopy() {
 	type Thing string
 
@@ -0,0 +1,139 @@
+package goreg
+
+import (
+	"errors"
+	"iter"
+	"log/slog"
+	"sync"
+)
+
+// ErrFeedTruncated is returned by [FeedRegistry.ChangesSince] when the requested changes are no longer in the feed.
+// The consumer has to resync from [FeedRegistry.Snapshot].
+var ErrFeedTruncated = errors.New("goreg: change feed truncated")
+
+// FeedOptions configures a [FeedRegistry].
+type FeedOptions struct {
+	// Capacity is the number of recent changes kept in the feed. Defaults to 1024.
+	Capacity int
+}
+
+// FeedRegistry is a registry that keeps a bounded in-memory feed of its recent mutations. It wraps another registry.
+//
+// Every mutation gets a sequence number, starting at 1. Consumers remember the sequence number of the last change
+// they have seen and catch up with [FeedRegistry.ChangesSince]. Unlike callbacks, nothing is lost while a consumer
+// is not looking, as long as it doesn't fall further behind than the capacity of the feed.
+type FeedRegistry[T any] struct {
+	reg  Registry[T]
+	opts FeedOptions
+
+	mu      sync.RWMutex // serializes mutations so that the sequence order matches the apply order
+	log     changeLog[T]
+	changed chan struct{}
+}
+
+// NewFeedRegistry creates a new [FeedRegistry] wrapping reg.
+//
+// A nil opts is equivalent to a zero [FeedOptions].
+func NewFeedRegistry[T any](reg Registry[T], opts *FeedOptions) *FeedRegistry[T] {
+	r := &FeedRegistry[T]{reg: reg, changed: make(chan struct{})}
+	if opts != nil {
+		r.opts = *opts
+	}
+	if r.opts.Capacity <= 0 {
+		r.opts.Capacity = 1024
+	}
+	r.log = newChangeLog[T](r.opts.Capacity)
+	return r
+}
+
+// Register registers an object under the ID.
+func (r *FeedRegistry[T]) Register(id string, obj T) {
+	r.mutate(Change[T]{Op: OpRegister, ID: id, Value: obj})
+}
+
+// Unregister unregisters an object under the ID.
+func (r *FeedRegistry[T]) Unregister(id string) {
+	r.mutate(Change[T]{Op: OpUnregister, ID: id})
+}
+
+// Get returns the object under the ID.
+func (r *FeedRegistry[T]) Get(id string) (obj T, ok bool) {
+	return r.reg.Get(id)
+}
+
+// MustGet returns the object under the ID and logs error if not found.
+func (r *FeedRegistry[T]) MustGet(id string) T {
+	obj, ok := r.Get(id)
+	if !ok {
+		slog.Error("*goreg.FeedRegistry: object not found", "id", id)
+	}
+	return obj
+}
+
+// Len returns the number of items in the registry.
+func (r *FeedRegistry[T]) Len() int {
+	return r.reg.Len()
+}
+
+// Reset wipes the registry.
+func (r *FeedRegistry[T]) Reset() {
+	r.mutate(Change[T]{Op: OpReset})
+}
+
+// Iter returns an iterator over key-value pairs. See the [iter] package documentation for more details.
+func (r *FeedRegistry[T]) Iter() iter.Seq2[string, T] {
+	return r.reg.Iter()
+}
+
+// String returns a string representation of the registry.
+func (r *FeedRegistry[T]) String() string {
+	return r.reg.String()
+}
+
+// Seq returns the sequence number of the last mutation, or 0 if there were none.
+func (r *FeedRegistry[T]) Seq() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.log.seq
+}
+
+// ChangesSince returns the changes after the sequence number seq, oldest first. A consumer that has seen nothing yet
+// passes 0. If some of the changes were already dropped from the feed, or seq is ahead of the feed,
+// it returns [ErrFeedTruncated].
+func (r *FeedRegistry[T]) ChangesSince(seq uint64) ([]Change[T], error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	changes, ok := r.log.since(seq)
+	if !ok {
+		return nil, ErrFeedTruncated
+	}
+	return changes, nil
+}
+
+// Snapshot returns the entries of the registry together with the sequence number of the last mutation
+// they include. A consumer resyncing after [ErrFeedTruncated] loads the entries and continues with ChangesSince.
+func (r *FeedRegistry[T]) Snapshot() (entries []Entry[T], seq uint64) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return Entries(r.reg), r.log.seq
+}
+
+// Changed returns a channel that is closed on the next mutation. It lets consumers wait for changes
+// instead of polling ChangesSince.
+func (r *FeedRegistry[T]) Changed() <-chan struct{} {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.changed
+}
+
+func (r *FeedRegistry[T]) mutate(c Change[T]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c.apply(r.reg)
+	r.log.add(c)
+
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
@@ -0,0 +1,91 @@
+package goreg_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/MatusOllah/goreg"
+)
+
+func TestFeedRegistry_ChangesSince(t *testing.T) {
+	reg := goreg.NewFeedRegistry[int](goreg.NewStandardRegistry[int](), nil)
+	reg.Register("kozmeker", 69)
+	reg.Register("kajsmentke", 42)
+	reg.Unregister("kozmeker")
+	reg.Reset()
+
+	changes, err := reg.ChangesSince(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []goreg.Change[int]{
+		{Seq: 1, Op: goreg.OpRegister, ID: "kozmeker", Value: 69},
+		{Seq: 2, Op: goreg.OpRegister, ID: "kajsmentke", Value: 42},
+		{Seq: 3, Op: goreg.OpUnregister, ID: "kozmeker"},
+		{Seq: 4, Op: goreg.OpReset},
+	}
+	if !slices.Equal(changes, expected) {
+		t.Errorf("expected %v, got %v", expected, changes)
+	}
+
+	changes, err = reg.ChangesSince(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(changes, expected[2:]) {
+		t.Errorf("expected %v, got %v", expected[2:], changes)
+	}
+
+	if changes, err := reg.ChangesSince(reg.Seq()); err != nil || len(changes) != 0 {
+		t.Errorf("expected no changes, got %v, %v", changes, err)
+	}
+	if _, err := reg.ChangesSince(5); !errors.Is(err, goreg.ErrFeedTruncated) {
+		t.Errorf("expected ErrFeedTruncated for a future sequence number, got %v", err)
+	}
+}
+
+func TestFeedRegistry_Truncated(t *testing.T) {
+	reg := goreg.NewFeedRegistry[int](goreg.NewStandardRegistry[int](), &goreg.FeedOptions{Capacity: 3})
+	for i := range 5 {
+		reg.Register("kozmeker", i)
+	}
+
+	if _, err := reg.ChangesSince(1); !errors.Is(err, goreg.ErrFeedTruncated) {
+		t.Errorf("expected ErrFeedTruncated, got %v", err)
+	}
+	changes, err := reg.ChangesSince(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 3 || changes[0].Seq != 3 || changes[2].Seq != 5 {
+		t.Errorf("expected changes 3 to 5, got %v", changes)
+	}
+
+	// Resync from a snapshot.
+	entries, seq := reg.Snapshot()
+	if seq != 5 || !slices.Equal(entries, []goreg.Entry[int]{{Key: "kozmeker", Value: 4}}) {
+		t.Errorf("unexpected snapshot %v at %d", entries, seq)
+	}
+	if changes, err := reg.ChangesSince(seq); err != nil || len(changes) != 0 {
+		t.Errorf("expected no changes, got %v, %v", changes, err)
+	}
+}
+
+func TestFeedRegistry_Changed(t *testing.T) {
+	reg := goreg.NewFeedRegistry[int](goreg.NewStandardRegistry[int](), nil)
+
+	changed := reg.Changed()
+	select {
+	case <-changed:
+		t.Fatal("expected channel to be open before a mutation")
+	default:
+	}
+
+	reg.Register("kozmeker", 69)
+	select {
+	case <-changed:
+	default:
+		t.Fatal("expected channel to be closed after a mutation")
+	}
+}
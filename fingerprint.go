@@ -0,0 +1,120 @@
+package goreg
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"slices"
+	"strings"
+)
+
+// Fingerprint returns a hash of the contents of reg computed with a hash from newHash, such as [crypto/sha256.New].
+//
+// The hash covers the IDs in sorted order along with their values encoded as JSON, so it doesn't depend on
+// the iteration order and is the same for a [StandardRegistry] and an [OrderedRegistry] with the same contents.
+// Values must encode to JSON deterministically, which holds for structs, maps and the basic types.
+func Fingerprint[T any](reg Registry[T], newHash func() hash.Hash) ([]byte, error) {
+	entries, err := canonicalEntries(reg)
+	if err != nil {
+		return nil, err
+	}
+	sortCanonical(entries)
+	return hashCanonical(entries, newHash), nil
+}
+
+// OrderedFingerprint is like [Fingerprint], but hashes the entries in iteration order, so registries with
+// the same contents in a different order have different fingerprints.
+func OrderedFingerprint[T any](reg Registry[T], newHash func() hash.Hash) ([]byte, error) {
+	entries, err := canonicalEntries(reg)
+	if err != nil {
+		return nil, err
+	}
+	return hashCanonical(entries, newHash), nil
+}
+
+// FingerprintBy computes a [Fingerprint] for every group of IDs in reg, where group returns the group of an ID,
+// such as [Namespace]. Comparing the results with [DiffFingerprints] tells which groups differ.
+func FingerprintBy[T any](reg Registry[T], newHash func() hash.Hash, group func(id string) string) (map[string][]byte, error) {
+	entries, err := canonicalEntries(reg)
+	if err != nil {
+		return nil, err
+	}
+	sortCanonical(entries)
+
+	groups := make(map[string][]Entry[[]byte])
+	for _, e := range entries {
+		g := group(e.Key)
+		groups[g] = append(groups[g], e)
+	}
+
+	sums := make(map[string][]byte, len(groups))
+	for g, entries := range groups {
+		sums[g] = hashCanonical(entries, newHash)
+	}
+	return sums, nil
+}
+
+// Namespace returns the part of id before the first colon, such as "minecraft" for "minecraft:stone",
+// or an empty string if id has no colon.
+func Namespace(id string) string {
+	ns, _, ok := strings.Cut(id, ":")
+	if !ok {
+		return ""
+	}
+	return ns
+}
+
+// DiffFingerprints returns the groups, in sorted order, whose fingerprints differ between a and b
+// or which are only present in one of them.
+func DiffFingerprints(a, b map[string][]byte) []string {
+	var diff []string
+	for g, sum := range a {
+		if other, ok := b[g]; !ok || !bytes.Equal(sum, other) {
+			diff = append(diff, g)
+		}
+	}
+	for g := range b {
+		if _, ok := a[g]; !ok {
+			diff = append(diff, g)
+		}
+	}
+	slices.Sort(diff)
+	return diff
+}
+
+// canonicalEntries returns the entries of reg in iteration order with their values encoded as JSON.
+func canonicalEntries[T any](reg Registry[T]) ([]Entry[[]byte], error) {
+	entries := make([]Entry[[]byte], 0, reg.Len())
+	for id, obj := range reg.Iter() {
+		data, err := json.Marshal(obj)
+		if err != nil {
+			return nil, fmt.Errorf("goreg: encoding %q: %w", id, err)
+		}
+		entries = append(entries, Entry[[]byte]{Key: id, Value: data})
+	}
+	return entries, nil
+}
+
+// sortCanonical sorts entries by ID, and by value for duplicate IDs in an [OrderedRegistry].
+func sortCanonical(entries []Entry[[]byte]) {
+	slices.SortFunc(entries, func(a, b Entry[[]byte]) int {
+		return cmp.Or(strings.Compare(a.Key, b.Key), bytes.Compare(a.Value, b.Value))
+	})
+}
+
+// hashCanonical hashes the entries as length-prefixed IDs and values, preceded by the number of entries.
+func hashCanonical(entries []Entry[[]byte], newHash func() hash.Hash) []byte {
+	h := newHash()
+	var buf [binary.MaxVarintLen64]byte
+	h.Write(buf[:binary.PutUvarint(buf[:], uint64(len(entries)))])
+	for _, e := range entries {
+		h.Write(buf[:binary.PutUvarint(buf[:], uint64(len(e.Key)))])
+		h.Write([]byte(e.Key))
+		h.Write(buf[:binary.PutUvarint(buf[:], uint64(len(e.Value)))])
+		h.Write(e.Value)
+	}
+	return h.Sum(nil)
+}
@@ -0,0 +1,117 @@
+package goreg_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"slices"
+	"testing"
+
+	"github.com/MatusOllah/goreg"
+)
+
+func TestFingerprint(t *testing.T) {
+	standard := goreg.NewStandardRegistry[map[string]int]()
+	standard.Register("kozmeker", map[string]int{"a": 1, "b": 2})
+	standard.Register("kajsmentke", map[string]int{"c": 3})
+
+	ordered := goreg.NewOrderedRegistry[map[string]int]()
+	ordered.Register("kajsmentke", map[string]int{"c": 3})
+	ordered.Register("kozmeker", map[string]int{"b": 2, "a": 1})
+
+	sum1, err := goreg.Fingerprint[map[string]int](standard, sha256.New)
+	if err != nil {
+		t.Fatalf("failed to fingerprint: %v", err)
+	}
+	sum2, err := goreg.Fingerprint[map[string]int](ordered, sha256.New)
+	if err != nil {
+		t.Fatalf("failed to fingerprint: %v", err)
+	}
+	if !bytes.Equal(sum1, sum2) {
+		t.Errorf("expected equal fingerprints, got %x and %x", sum1, sum2)
+	}
+
+	standard.Register("kozmeker", map[string]int{"a": 1, "b": 3})
+	sum3, err := goreg.Fingerprint[map[string]int](standard, sha256.New)
+	if err != nil {
+		t.Fatalf("failed to fingerprint: %v", err)
+	}
+	if bytes.Equal(sum1, sum3) {
+		t.Error("expected fingerprints to differ after changing a value")
+	}
+}
+
+func TestFingerprint_Ambiguity(t *testing.T) {
+	reg1 := goreg.NewStandardRegistry[string]()
+	reg1.Register("ab", "c")
+	reg2 := goreg.NewStandardRegistry[string]()
+	reg2.Register("a", "bc")
+
+	sum1, _ := goreg.Fingerprint[string](reg1, sha256.New)
+	sum2, _ := goreg.Fingerprint[string](reg2, sha256.New)
+	if bytes.Equal(sum1, sum2) {
+		t.Error("expected fingerprints to differ")
+	}
+}
+
+func TestOrderedFingerprint(t *testing.T) {
+	reg1 := goreg.NewOrderedRegistry[int]()
+	reg1.Register("kozmeker", 69)
+	reg1.Register("kajsmentke", 42)
+
+	reg2 := goreg.NewOrderedRegistry[int]()
+	reg2.Register("kajsmentke", 42)
+	reg2.Register("kozmeker", 69)
+
+	sum1, _ := goreg.OrderedFingerprint[int](reg1, sha256.New)
+	sum2, _ := goreg.OrderedFingerprint[int](reg2, sha256.New)
+	if bytes.Equal(sum1, sum2) {
+		t.Error("expected fingerprints to differ for different orders")
+	}
+
+	sum3, _ := goreg.OrderedFingerprint[int](reg1, sha256.New)
+	if !bytes.Equal(sum1, sum3) {
+		t.Error("expected fingerprint to be stable")
+	}
+}
+
+func TestFingerprintBy(t *testing.T) {
+	server := goreg.NewStandardRegistry[int]()
+	server.Register("minecraft:stone", 1)
+	server.Register("minecraft:dirt", 2)
+	server.Register("mymod:ruby", 3)
+	server.Register("legacy", 4)
+
+	client := goreg.NewStandardRegistry[int]()
+	client.Register("minecraft:stone", 1)
+	client.Register("minecraft:dirt", 2)
+	client.Register("mymod:ruby", 5)
+	client.Register("othermod:gem", 6)
+
+	serverSums, err := goreg.FingerprintBy[int](server, sha256.New, goreg.Namespace)
+	if err != nil {
+		t.Fatalf("failed to fingerprint: %v", err)
+	}
+	clientSums, err := goreg.FingerprintBy[int](client, sha256.New, goreg.Namespace)
+	if err != nil {
+		t.Fatalf("failed to fingerprint: %v", err)
+	}
+
+	expected := []string{"", "mymod", "othermod"}
+	if diff := goreg.DiffFingerprints(serverSums, clientSums); !slices.Equal(diff, expected) {
+		t.Errorf("expected %q, got %q", expected, diff)
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	tests := map[string]string{
+		"minecraft:stone": "minecraft",
+		"a:b:c":           "a",
+		"stone":           "",
+		":stone":          "",
+	}
+	for id, expected := range tests {
+		if ns := goreg.Namespace(id); ns != expected {
+			t.Errorf("expected namespace %q for %q, got %q", expected, id, ns)
+		}
+	}
+}
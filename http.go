@@ -0,0 +1,351 @@
+package goreg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// HandlerOptions configures the handler returned by [NewHandler].
+type HandlerOptions struct {
+	// ReadOnly rejects PUT and DELETE requests with 405 Method Not Allowed.
+	ReadOnly bool
+
+	// Authorize wraps the handler, usually to check the credentials of a request before passing it on.
+	// It can tell reads from writes by the request method.
+	Authorize func(next http.Handler) http.Handler
+
+	// PageSize is the number of entries listed when the request doesn't set a limit. Defaults to 100.
+	PageSize int
+
+	// MaxPageSize is the maximum number of entries listed at once. Defaults to 1000.
+	MaxPageSize int
+
+	// MaxBodySize is the maximum size of a PUT request body. Defaults to 1 MiB.
+	MaxBodySize int64
+}
+
+// Page is a page of entries returned when listing a registry served by [NewHandler].
+type Page[T any] struct {
+	// Total is the number of entries in the registry.
+	Total int `json:"total"`
+
+	// Entries are the entries on the page, sorted by ID.
+	Entries []Entry[T] `json:"entries"`
+
+	// Next is the ID to pass as after to get the next page. It is empty on the last page.
+	Next string `json:"next,omitempty"`
+}
+
+// httpError is the body of an error response.
+type httpError struct {
+	Error string `json:"error"`
+}
+
+// seqRegistry is implemented by registries that number their mutations, like [FeedRegistry] and [Publisher].
+type seqRegistry interface {
+	Seq() uint64
+}
+
+type handler[T any] struct {
+	reg  Registry[T]
+	opts HandlerOptions
+
+	mu sync.Mutex // serializes conditional writes
+}
+
+// NewHandler returns an [http.Handler] that serves reg as JSON. Mount it with [http.StripPrefix]; paths below it are IDs,
+// with any slashes in them escaped as %2F.
+//
+//   - GET / lists the entries sorted by ID, a page at a time ([Page]). The query parameters limit and after select the page.
+//   - GET /{id} returns the value under the ID, HEAD /{id} only tells whether it exists.
+//   - PUT /{id} registers the JSON value in the body under the ID.
+//   - DELETE /{id} unregisters the ID.
+//   - DELETE / resets the registry.
+//
+// If reg has a Seq method, like [FeedRegistry] and [Publisher], responses carry it as their ETag, GET honors If-None-Match,
+// and PUT and DELETE honor If-Match, so clients can make sure nobody changed the registry since they read it.
+// Sequence numbers start over when the process restarts, so ETags also carry a random per-process prefix.
+// Other registries can be changed without the handler knowing, so their responses have no ETag
+// and If-Match only accepts *.
+//
+// A nil opts is equivalent to a zero [HandlerOptions].
+func NewHandler[T any](reg Registry[T], opts *HandlerOptions) http.Handler {
+	h := &handler[T]{reg: reg}
+	if opts != nil {
+		h.opts = *opts
+	}
+	if h.opts.PageSize <= 0 {
+		h.opts.PageSize = 100
+	}
+	if h.opts.MaxPageSize <= 0 {
+		h.opts.MaxPageSize = 1000
+	}
+	if h.opts.MaxBodySize <= 0 {
+		h.opts.MaxBodySize = 1 << 20
+	}
+
+	if h.opts.Authorize != nil {
+		return h.opts.Authorize(h)
+	}
+	return h
+}
+
+func (h *handler[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id, err := url.PathUnescape(strings.TrimPrefix(r.URL.EscapedPath(), "/"))
+	if err != nil {
+		writeHTTPError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if id == "" {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			h.list(w, r)
+		case http.MethodDelete:
+			if h.opts.ReadOnly {
+				h.notAllowed(w, false)
+				return
+			}
+			h.reset(w, r)
+		default:
+			h.notAllowed(w, false)
+		}
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		h.get(w, r, id)
+	case http.MethodPut:
+		if h.opts.ReadOnly {
+			h.notAllowed(w, true)
+			return
+		}
+		h.put(w, r, id)
+	case http.MethodDelete:
+		if h.opts.ReadOnly {
+			h.notAllowed(w, true)
+			return
+		}
+		h.delete(w, r, id)
+	default:
+		h.notAllowed(w, true)
+	}
+}
+
+func (h *handler[T]) list(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := h.opts.PageSize
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			writeHTTPError(w, http.StatusBadRequest, fmt.Errorf("goreg: invalid limit %q", s))
+			return
+		}
+		limit = min(n, h.opts.MaxPageSize)
+	}
+	after := q.Get("after")
+
+	etag, hasETag := h.etag()
+	entries := Entries(h.reg)
+	slices.SortFunc(entries, func(a, b Entry[T]) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	page := Page[T]{Total: len(entries)}
+	i, _ := slices.BinarySearchFunc(entries, after, func(e Entry[T], id string) int {
+		return strings.Compare(e.Key, id)
+	})
+	if i < len(entries) && entries[i].Key == after {
+		i++
+	}
+	page.Entries = entries[i:min(i+limit, len(entries))]
+	if i+limit < len(entries) {
+		page.Next = page.Entries[len(page.Entries)-1].Key
+	}
+
+	if hasETag {
+		w.Header().Set("ETag", etag)
+	}
+	writeJSON(w, r, http.StatusOK, page)
+}
+
+func (h *handler[T]) get(w http.ResponseWriter, r *http.Request, id string) {
+	etag, hasETag := h.etag()
+	obj, ok := h.reg.Get(id)
+	if !ok {
+		writeHTTPError(w, http.StatusNotFound, fmt.Errorf("goreg: object %q not found", id))
+		return
+	}
+
+	if hasETag {
+		w.Header().Set("ETag", etag)
+		if inm := r.Header.Get("If-None-Match"); strings.TrimSpace(inm) == "*" || matchETag(inm, etag, true) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	writeJSON(w, r, http.StatusOK, obj)
+}
+
+func (h *handler[T]) put(w http.ResponseWriter, r *http.Request, id string) {
+	var obj T
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, h.opts.MaxBodySize))
+	err := dec.Decode(&obj)
+	if err == nil {
+		// The body holds a single value.
+		if _, tokErr := dec.Token(); tokErr != io.EOF {
+			err = tokErr
+			if err == nil {
+				err = errors.New("goreg: unexpected data after the value")
+			}
+		}
+	}
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeHTTPError(w, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		writeHTTPError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, exists := h.reg.Get(id)
+	if !h.checkPrecondition(w, r, exists) {
+		return
+	}
+	h.reg.Register(id, obj)
+
+	h.setETag(w)
+	if exists {
+		w.WriteHeader(http.StatusNoContent)
+	} else {
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+func (h *handler[T]) delete(w http.ResponseWriter, r *http.Request, id string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, exists := h.reg.Get(id)
+	if !h.checkPrecondition(w, r, exists) {
+		return
+	}
+	if !exists {
+		writeHTTPError(w, http.StatusNotFound, fmt.Errorf("goreg: object %q not found", id))
+		return
+	}
+	h.reg.Unregister(id)
+
+	h.setETag(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler[T]) reset(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.checkPrecondition(w, r, true) {
+		return
+	}
+	h.reg.Reset()
+
+	h.setETag(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkPrecondition checks the If-Match header of a write and responds with 412 Precondition Failed if it doesn't match.
+func (h *handler[T]) checkPrecondition(w http.ResponseWriter, r *http.Request, exists bool) bool {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		return true
+	}
+	etag, hasETag := h.etag()
+	if strings.TrimSpace(ifMatch) == "*" {
+		if exists {
+			return true
+		}
+	} else if hasETag && matchETag(ifMatch, etag, false) {
+		return true
+	}
+	h.setETag(w)
+	writeHTTPError(w, http.StatusPreconditionFailed, errors.New("goreg: registry changed"))
+	return false
+}
+
+func (h *handler[T]) notAllowed(w http.ResponseWriter, item bool) {
+	allow := "GET, HEAD"
+	if !h.opts.ReadOnly {
+		if item {
+			allow += ", PUT"
+		}
+		allow += ", DELETE"
+	}
+	w.Header().Set("Allow", allow)
+	writeHTTPError(w, http.StatusMethodNotAllowed, errors.New("goreg: method not allowed"))
+}
+
+// etagPrefix keeps the ETags of a restarted process from matching the ones handed out before.
+var etagPrefix = newEpoch()
+
+// etag returns the sequence number of the registry as an ETag.
+// It reports false if the registry doesn't number its mutations.
+func (h *handler[T]) etag() (string, bool) {
+	s, ok := h.reg.(seqRegistry)
+	if !ok {
+		return "", false
+	}
+	return `"` + etagPrefix + "-" + strconv.FormatUint(s.Seq(), 10) + `"`, true
+}
+
+// setETag sets the ETag header of the response, if the registry has one.
+func (h *handler[T]) setETag(w http.ResponseWriter) {
+	if etag, ok := h.etag(); ok {
+		w.Header().Set("ETag", etag)
+	}
+}
+
+// matchETag reports whether the comma-separated list of ETags in header contains etag.
+// Weak comparison, used for If-None-Match, ignores the W/ prefix; strong comparison, used for If-Match,
+// never matches weak ETags.
+func matchETag(header, etag string, weak bool) bool {
+	for _, s := range strings.Split(header, ",") {
+		s = strings.TrimSpace(s)
+		if weak {
+			s = strings.TrimPrefix(s, "W/")
+		}
+		if s == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if r.Method == http.MethodHead {
+		return
+	}
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeHTTPError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(httpError{Error: err.Error()})
+}
@@ -0,0 +1,268 @@
+package goreg_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/MatusOllah/goreg"
+)
+
+// do sends a request to h and returns the recorded response.
+func do(h http.Handler, method, target, body string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandler(t *testing.T) {
+	reg := goreg.NewStandardRegistry[int]()
+	reg.Register("kozmeker", 69)
+	h := goreg.NewHandler[int](reg, nil)
+
+	rec := do(h, http.MethodGet, "/kozmeker", "")
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != "69" {
+		t.Errorf("expected 200 69, got %d %s", rec.Code, rec.Body)
+	}
+	if rec := do(h, http.MethodHead, "/kozmeker", ""); rec.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rec.Code)
+	}
+	if rec := do(h, http.MethodHead, "/kajsmentke", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rec.Code)
+	}
+
+	if rec := do(h, http.MethodPut, "/kajsmentke", "42"); rec.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d %s", rec.Code, rec.Body)
+	}
+	if rec := do(h, http.MethodPut, "/kajsmentke", "43"); rec.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d %s", rec.Code, rec.Body)
+	}
+	if val, _ := reg.Get("kajsmentke"); val != 43 {
+		t.Errorf("expected 43, got %d", val)
+	}
+	if rec := do(h, http.MethodPut, "/kajsmentke", "nope"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rec.Code)
+	}
+	for _, body := range []string{"44 45", "44}", `44 "nope"`} {
+		if rec := do(h, http.MethodPut, "/kajsmentke", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %q, got %d", body, rec.Code)
+		}
+	}
+	if rec := do(h, http.MethodPut, "/kajsmentke", " 44\n"); rec.Code != http.StatusNoContent {
+		t.Errorf("expected 204 with surrounding whitespace, got %d %s", rec.Code, rec.Body)
+	}
+
+	if rec := do(h, http.MethodDelete, "/kozmeker", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d %s", rec.Code, rec.Body)
+	}
+	if rec := do(h, http.MethodDelete, "/kozmeker", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rec.Code)
+	}
+
+	// Slashes in IDs are escaped.
+	do(h, http.MethodPut, "/mod%2Fkocurkovo", "1")
+	if _, ok := reg.Get("mod/kocurkovo"); !ok {
+		t.Error("expected key mod/kocurkovo to be registered")
+	}
+
+	if rec := do(h, http.MethodPost, "/", ""); rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != "GET, HEAD, DELETE" {
+		t.Errorf("expected 405 with Allow GET, HEAD, DELETE, got %d %q", rec.Code, rec.Header().Get("Allow"))
+	}
+
+	if rec := do(h, http.MethodDelete, "/", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d %s", rec.Code, rec.Body)
+	}
+	if reg.Len() != 0 {
+		t.Errorf("expected length 0, got %d", reg.Len())
+	}
+}
+
+func TestHandler_List(t *testing.T) {
+	reg := goreg.NewStandardRegistry[int]()
+	for i, id := range []string{"e", "c", "a", "d", "b"} {
+		reg.Register(id, i)
+	}
+	h := goreg.NewHandler[int](reg, &goreg.HandlerOptions{PageSize: 2})
+
+	var ids []string
+	after := ""
+	for range 10 {
+		rec := do(h, http.MethodGet, "/?after="+after, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d %s", rec.Code, rec.Body)
+		}
+		var page goreg.Page[int]
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+			t.Fatal(err)
+		}
+		if page.Total != 5 {
+			t.Errorf("expected total 5, got %d", page.Total)
+		}
+		for _, e := range page.Entries {
+			ids = append(ids, e.Key)
+		}
+		if page.Next == "" {
+			break
+		}
+		after = page.Next
+	}
+	if expected := []string{"a", "b", "c", "d", "e"}; !slices.Equal(ids, expected) {
+		t.Errorf("expected %v, got %v", expected, ids)
+	}
+
+	var page goreg.Page[int]
+	json.Unmarshal(do(h, http.MethodGet, "/?limit=4", "").Body.Bytes(), &page)
+	if len(page.Entries) != 4 || page.Next != "d" {
+		t.Errorf("expected 4 entries up to d, got %+v", page)
+	}
+	if rec := do(h, http.MethodGet, "/?limit=0", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rec.Code)
+	}
+}
+
+func TestHandler_ETag(t *testing.T) {
+	reg := goreg.NewFeedRegistry[int](goreg.NewStandardRegistry[int](), nil)
+	reg.Register("kozmeker", 69)
+	h := goreg.NewHandler[int](reg, nil)
+
+	etag := do(h, http.MethodGet, "/kozmeker", "").Header().Get("ETag")
+	if !strings.HasSuffix(etag, `-1"`) {
+		t.Errorf(`expected ETag of generation 1, got %s`, etag)
+	}
+	if rec := do(h, http.MethodGet, "/kozmeker", "", "If-None-Match", etag); rec.Code != http.StatusNotModified {
+		t.Errorf("expected 304, got %d", rec.Code)
+	}
+	if rec := do(h, http.MethodGet, "/kozmeker", "", "If-None-Match", "W/"+etag); rec.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for weak ETag, got %d", rec.Code)
+	}
+
+	// If-Match uses strong comparison, so a weak ETag never matches.
+	if rec := do(h, http.MethodPut, "/kozmeker", "70", "If-Match", "W/"+etag); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected 412 for weak ETag, got %d", rec.Code)
+	}
+	if rec := do(h, http.MethodPut, "/kozmeker", "70", "If-Match", `"1"`); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected 412 for ETag without prefix, got %d", rec.Code)
+	}
+
+	rec := do(h, http.MethodPut, "/kozmeker", "70", "If-Match", etag)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d %s", rec.Code, rec.Body)
+	}
+	if next := rec.Header().Get("ETag"); next != strings.TrimSuffix(etag, `1"`)+`2"` {
+		t.Errorf(`expected ETag of generation 2, got %s`, next)
+	}
+
+	// A stale ETag is rejected.
+	if rec := do(h, http.MethodPut, "/kozmeker", "71", "If-Match", etag); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected 412, got %d", rec.Code)
+	}
+	if rec := do(h, http.MethodDelete, "/kozmeker", "", "If-Match", etag); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected 412, got %d", rec.Code)
+	}
+	if val, _ := reg.Get("kozmeker"); val != 70 {
+		t.Errorf("expected 70, got %d", val)
+	}
+
+	// If-Match: * only matches existing IDs.
+	if rec := do(h, http.MethodPut, "/kajsmentke", "42", "If-Match", "*"); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected 412, got %d", rec.Code)
+	}
+	if rec := do(h, http.MethodPut, "/kozmeker", "42", "If-Match", "*"); rec.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", rec.Code)
+	}
+}
+
+func TestHandler_NoETag(t *testing.T) {
+	reg := goreg.NewStandardRegistry[int]()
+	reg.Register("kozmeker", 69)
+	h := goreg.NewHandler[int](reg, nil)
+
+	rec := do(h, http.MethodGet, "/kozmeker", "")
+	if etag := rec.Header().Get("ETag"); etag != "" {
+		t.Errorf("expected no ETag, got %s", etag)
+	}
+	if rec := do(h, http.MethodGet, "/kozmeker", "", "If-None-Match", "*"); rec.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rec.Code)
+	}
+
+	// A write that bypasses the handler would go unnoticed, so no ETag matches.
+	if rec := do(h, http.MethodPut, "/kozmeker", "70", "If-Match", `"x-0"`); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected 412, got %d", rec.Code)
+	}
+	if rec := do(h, http.MethodPut, "/kozmeker", "70", "If-Match", "*"); rec.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", rec.Code)
+	}
+}
+
+func TestHandler_ReadOnly(t *testing.T) {
+	reg := goreg.NewStandardRegistry[int]()
+	reg.Register("kozmeker", 69)
+	h := goreg.NewHandler[int](reg, &goreg.HandlerOptions{ReadOnly: true})
+
+	for _, method := range []string{http.MethodPut, http.MethodDelete} {
+		rec := do(h, method, "/kozmeker", "70")
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("expected 405 for %s, got %d", method, rec.Code)
+		}
+		if rec.Header().Get("Allow") != "GET, HEAD" {
+			t.Errorf("expected Allow GET, HEAD, got %q", rec.Header().Get("Allow"))
+		}
+	}
+	if val, _ := reg.Get("kozmeker"); val != 69 {
+		t.Errorf("expected 69, got %d", val)
+	}
+}
+
+func TestHandler_Authorize(t *testing.T) {
+	reg := goreg.NewStandardRegistry[int]()
+	reg.Register("kozmeker", 69)
+
+	// Anyone can read, only admins can write.
+	auth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead && r.Header.Get("Authorization") != "Bearer admin" {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	srv := httptest.NewServer(http.StripPrefix("/registry", goreg.NewHandler[int](reg, &goreg.HandlerOptions{Authorize: auth})))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/registry/kozmeker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200, got %d", resp.StatusCode)
+	}
+
+	put := func(token string) int {
+		req, _ := http.NewRequest(http.MethodPut, srv.URL+"/registry/kozmeker", strings.NewReader("70"))
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := put("guest"); code != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", code)
+	}
+	if code := put("admin"); code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", code)
+	}
+	if val, _ := reg.Get("kozmeker"); val != 70 {
+		t.Errorf("expected 70, got %d", val)
+	}
+}
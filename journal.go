@@ -0,0 +1,435 @@
+package goreg
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"iter"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+const (
+	journalSnapshotName = "snapshot.json"
+	journalLogName      = "journal.log"
+
+	frameHeaderSize = 8
+	maxFrameSize    = 64 << 20
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+var errFrameChecksum = errors.New("goreg: frame checksum mismatch")
+
+// JournalOptions configures a [JournaledRegistry].
+type JournalOptions struct {
+	// CompactThreshold is the log size in bytes after which the journal is compacted.
+	// Defaults to 4 MiB. A negative value disables automatic compaction.
+	CompactThreshold int64
+
+	// Sync makes every appended record fsync'd before the mutation returns.
+	Sync bool
+
+	// Perm is the permission used for the journal files. Defaults to 0644.
+	Perm fs.FileMode
+
+	// OnError is called when writing to the journal fails, in which case the mutation is not applied.
+	// Defaults to logging the error with [log/slog].
+	OnError func(err error)
+}
+
+// JournaledRegistry is a registry that is persisted to an append-only write-ahead journal. It wraps another registry.
+//
+// The journal lives in a directory and consists of a snapshot and a log.
+// Every mutation is appended to the log as a length-prefixed, checksummed record before it is applied.
+// Once the log grows past the compaction threshold, the snapshot is rewritten and the log is emptied.
+type JournaledRegistry[T any] struct {
+	reg  Registry[T]
+	dir  string
+	opts JournalOptions
+
+	mu     sync.Mutex // serializes mutations so that the log order matches the apply order
+	log    *os.File
+	size   int64
+	seq    uint64 // sequence number of the last journaled mutation
+	broken bool   // a failed write left a partial record in the log
+	closed bool
+}
+
+// journalSnapshot is the snapshot file of a journal.
+type journalSnapshot[T any] struct {
+	// Seq is the sequence number of the last mutation in the snapshot.
+	// Log records at or below it are already in the snapshot and skipped on replay.
+	Seq     uint64     `json:"seq"`
+	Entries []Entry[T] `json:"entries"`
+}
+
+// OpenJournaledRegistry creates a new [JournaledRegistry] backed by the journal in dir, creating the directory if needed.
+// The snapshot and log are replayed into reg, replacing its contents.
+// A torn or zero-filled record at the end of the log, left behind by a crash, is truncated.
+//
+// A nil opts is equivalent to a zero [JournalOptions].
+func OpenJournaledRegistry[T any](reg Registry[T], dir string, opts *JournalOptions) (*JournaledRegistry[T], error) {
+	r := &JournaledRegistry[T]{reg: reg, dir: dir}
+	if opts != nil {
+		r.opts = *opts
+	}
+	if r.opts.CompactThreshold == 0 {
+		r.opts.CompactThreshold = 4 << 20
+	}
+	if r.opts.Perm == 0 {
+		r.opts.Perm = 0o644
+	}
+	if r.opts.OnError == nil {
+		r.opts.OnError = func(err error) {
+			slog.Error("*goreg.JournaledRegistry: journal write failed", "dir", dir, "err", err)
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("goreg: opening journal %s: %w", dir, err)
+	}
+
+	if err := r.loadSnapshot(); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, journalLogName), os.O_RDWR|os.O_CREATE, r.opts.Perm)
+	if err != nil {
+		return nil, fmt.Errorf("goreg: opening journal %s: %w", dir, err)
+	}
+	r.log = f
+
+	if err := r.replay(); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *JournaledRegistry[T]) loadSnapshot() error {
+	path := filepath.Join(r.dir, journalSnapshotName)
+	r.reg.Reset()
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("goreg: loading %s: %w", path, err)
+	}
+
+	var snap journalSnapshot[T]
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("goreg: loading %s: %w: %w", path, ErrCorrupt, err)
+	}
+
+	for _, e := range snap.Entries {
+		r.reg.Register(e.Key, e.Value)
+	}
+	r.seq = snap.Seq
+
+	return nil
+}
+
+func (r *JournaledRegistry[T]) replay() error {
+	fi, err := r.log.Stat()
+	if err != nil {
+		return fmt.Errorf("goreg: replaying journal %s: %w", r.dir, err)
+	}
+
+	br := bufio.NewReader(r.log)
+	var off int64
+	for {
+		payload, err := readFrame(br)
+		if err == io.EOF {
+			break
+		}
+		var c Change[T]
+		if err == nil {
+			err = json.Unmarshal(payload, &c)
+		}
+		if err != nil {
+			torn, terr := r.tornTail(off, fi.Size())
+			if terr != nil {
+				return fmt.Errorf("goreg: replaying journal %s: %w", r.dir, terr)
+			}
+			if !torn {
+				return fmt.Errorf("goreg: replaying journal %s at offset %d: %w: %w", r.dir, off, ErrCorrupt, err)
+			}
+			if err := r.log.Truncate(off); err != nil {
+				return fmt.Errorf("goreg: truncating journal %s: %w", r.dir, err)
+			}
+			break
+		}
+		if c.Seq > r.seq {
+			if err := c.apply(r.reg); err != nil {
+				return fmt.Errorf("goreg: replaying journal %s at offset %d: %w: %w", r.dir, off, ErrCorrupt, err)
+			}
+			r.seq = c.Seq
+		}
+
+		off += int64(frameHeaderSize + len(payload))
+	}
+
+	if _, err := r.log.Seek(off, io.SeekStart); err != nil {
+		return fmt.Errorf("goreg: replaying journal %s: %w", r.dir, err)
+	}
+	r.size = off
+
+	return nil
+}
+
+// tornTail reports whether the invalid frame at off is a torn write at the end of a log of the given size:
+// either the frame is incomplete or reaches the end of the log, or the rest of the log is zero-filled,
+// as some file systems leave it after a crash.
+func (r *JournaledRegistry[T]) tornTail(off, size int64) (bool, error) {
+	var hdr [frameHeaderSize]byte
+	if n, err := r.log.ReadAt(hdr[:], off); n < len(hdr) {
+		if err == io.EOF {
+			return true, nil
+		}
+		return false, err
+	}
+	if off+frameHeaderSize+int64(binary.LittleEndian.Uint32(hdr[0:4])) >= size {
+		return true, nil
+	}
+
+	buf := make([]byte, 32<<10)
+	sr := io.NewSectionReader(r.log, off, size-off)
+	for {
+		n, err := sr.Read(buf)
+		if slices.ContainsFunc(buf[:n], func(b byte) bool { return b != 0 }) {
+			return false, nil
+		}
+		if err == io.EOF {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+}
+
+// Dir returns the journal directory.
+func (r *JournaledRegistry[T]) Dir() string {
+	return r.dir
+}
+
+// Register registers an object under the ID.
+func (r *JournaledRegistry[T]) Register(id string, obj T) {
+	r.mutate(Change[T]{Op: OpRegister, ID: id, Value: obj})
+}
+
+// Unregister unregisters an object under the ID.
+func (r *JournaledRegistry[T]) Unregister(id string) {
+	r.mutate(Change[T]{Op: OpUnregister, ID: id})
+}
+
+// Get returns the object under the ID.
+func (r *JournaledRegistry[T]) Get(id string) (obj T, ok bool) {
+	return r.reg.Get(id)
+}
+
+// MustGet returns the object under the ID and logs error if not found.
+func (r *JournaledRegistry[T]) MustGet(id string) T {
+	obj, ok := r.Get(id)
+	if !ok {
+		slog.Error("*goreg.JournaledRegistry: object not found", "id", id)
+	}
+	return obj
+}
+
+// Len returns the number of items in the registry.
+func (r *JournaledRegistry[T]) Len() int {
+	return r.reg.Len()
+}
+
+// Reset wipes the registry.
+func (r *JournaledRegistry[T]) Reset() {
+	r.mutate(Change[T]{Op: OpReset})
+}
+
+// Iter returns an iterator over key-value pairs. See the [iter] package documentation for more details.
+func (r *JournaledRegistry[T]) Iter() iter.Seq2[string, T] {
+	return r.reg.Iter()
+}
+
+// String returns a string representation of the registry.
+func (r *JournaledRegistry[T]) String() string {
+	return r.reg.String()
+}
+
+func (r *JournaledRegistry[T]) mutate(c Change[T]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Write-ahead: the mutation is only applied once it is in the log,
+	// so the registry never holds anything a restart would lose.
+	if err := r.append(c); err != nil {
+		r.opts.OnError(err)
+		return
+	}
+	c.apply(r.reg)
+
+	if r.opts.CompactThreshold > 0 && r.size > r.opts.CompactThreshold {
+		if err := r.compact(); err != nil {
+			r.opts.OnError(err)
+		}
+	}
+}
+
+func (r *JournaledRegistry[T]) append(c Change[T]) error {
+	if r.closed {
+		return fmt.Errorf("goreg: journal %s is closed", r.dir)
+	}
+	if r.broken {
+		return fmt.Errorf("goreg: journal %s is broken by a failed write", r.dir)
+	}
+
+	c.Seq = r.seq + 1
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("goreg: encoding journal record: %w", err)
+	}
+
+	n, err := writeFrame(r.log, payload)
+	if err != nil {
+		r.discard()
+		return fmt.Errorf("goreg: writing journal %s: %w", r.dir, err)
+	}
+
+	if r.opts.Sync {
+		if err := r.log.Sync(); err != nil {
+			r.discard()
+			return fmt.Errorf("goreg: syncing journal %s: %w", r.dir, err)
+		}
+	}
+
+	r.size += int64(n)
+	r.seq = c.Seq
+	return nil
+}
+
+// discard cuts the log back to its size before a failed append, so that the rejected record isn't replayed
+// and later records don't follow a partial one. If that fails too, the journal is marked broken and rejects
+// further mutations until a compaction empties the log.
+func (r *JournaledRegistry[T]) discard() {
+	if err := r.log.Truncate(r.size); err != nil {
+		r.broken = true
+		return
+	}
+	if _, err := r.log.Seek(r.size, io.SeekStart); err != nil {
+		r.broken = true
+	}
+}
+
+// Size returns the current size of the log in bytes.
+func (r *JournaledRegistry[T]) Size() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.size
+}
+
+// Compact rewrites the snapshot from the current contents of the registry and empties the log.
+func (r *JournaledRegistry[T]) Compact() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.compact()
+}
+
+func (r *JournaledRegistry[T]) compact() error {
+	if r.closed {
+		return fmt.Errorf("goreg: journal %s is closed", r.dir)
+	}
+
+	// The snapshot records the sequence number of the last mutation in it, so the records of a log
+	// that wasn't truncated because of a crash right after writing the snapshot are skipped on replay.
+	snap := journalSnapshot[T]{Seq: r.seq, Entries: Entries(r.reg)}
+	err := writeFileAtomic(filepath.Join(r.dir, journalSnapshotName), r.opts.Perm, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(snap)
+	})
+	if err != nil {
+		return fmt.Errorf("goreg: compacting journal %s: %w", r.dir, err)
+	}
+	// Make the rename durable before the log is emptied, or a crash could bring back the old
+	// snapshot without the records that were in the log.
+	if err := syncDir(r.dir); err != nil {
+		return fmt.Errorf("goreg: compacting journal %s: %w", r.dir, err)
+	}
+
+	if err := r.log.Truncate(0); err != nil {
+		return fmt.Errorf("goreg: compacting journal %s: %w", r.dir, err)
+	}
+	if _, err := r.log.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("goreg: compacting journal %s: %w", r.dir, err)
+	}
+	r.size = 0
+
+	if err := r.log.Sync(); err != nil {
+		return fmt.Errorf("goreg: compacting journal %s: %w", r.dir, err)
+	}
+	r.broken = false
+
+	return nil
+}
+
+// Close closes the journal. Mutations after Close are rejected and reported to [JournalOptions.OnError].
+func (r *JournaledRegistry[T]) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	return r.log.Close()
+}
+
+// writeFrame writes payload to w prefixed with its length and CRC-32C checksum.
+func writeFrame(w io.Writer, payload []byte) (int, error) {
+	buf := make([]byte, frameHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(buf[4:8], crc32.Checksum(payload, crcTable))
+	copy(buf[frameHeaderSize:], payload)
+	return w.Write(buf)
+}
+
+// readFrame reads a frame written by [writeFrame].
+// It returns [io.EOF] if there are no more frames and [io.ErrUnexpectedEOF] if the frame is incomplete.
+// On a checksum mismatch, it returns the payload along with errFrameChecksum.
+func readFrame(r io.Reader) ([]byte, error) {
+	var hdr [frameHeaderSize]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err
+	}
+
+	size := binary.LittleEndian.Uint32(hdr[0:4])
+	if size > maxFrameSize {
+		return nil, fmt.Errorf("goreg: frame too large (%d bytes)", size)
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	if crc32.Checksum(payload, crcTable) != binary.LittleEndian.Uint32(hdr[4:8]) {
+		return payload, errFrameChecksum
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,287 @@
+package goreg_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MatusOllah/goreg"
+)
+
+func TestJournaledRegistry_Replay(t *testing.T) {
+	dir := t.TempDir()
+
+	reg, err := goreg.OpenJournaledRegistry[int](goreg.NewStandardRegistry[int](), dir, nil)
+	if err != nil {
+		t.Fatalf("failed to open journal: %v", err)
+	}
+	reg.Register("kajsmentke", 42)
+	reg.Register("kozmeker", 69)
+	reg.Register("a", 1)
+	reg.Unregister("a")
+	if err := reg.Close(); err != nil {
+		t.Fatalf("failed to close journal: %v", err)
+	}
+
+	newReg, err := goreg.OpenJournaledRegistry[int](goreg.NewStandardRegistry[int](), dir, nil)
+	if err != nil {
+		t.Fatalf("failed to open journal: %v", err)
+	}
+	defer newReg.Close()
+
+	if val, ok := newReg.Get("kajsmentke"); !ok || val != 42 {
+		t.Errorf("expected 42, got %v", val)
+	}
+	if val, ok := newReg.Get("kozmeker"); !ok || val != 69 {
+		t.Errorf("expected 69, got %v", val)
+	}
+	if newReg.Len() != 2 {
+		t.Errorf("expected length 2, got %d", newReg.Len())
+	}
+}
+
+func TestJournaledRegistry_Reset(t *testing.T) {
+	dir := t.TempDir()
+
+	reg, err := goreg.OpenJournaledRegistry[int](goreg.NewStandardRegistry[int](), dir, nil)
+	if err != nil {
+		t.Fatalf("failed to open journal: %v", err)
+	}
+	reg.Register("kajsmentke", 42)
+	reg.Reset()
+	reg.Register("kozmeker", 69)
+	reg.Close()
+
+	newReg, err := goreg.OpenJournaledRegistry[int](goreg.NewStandardRegistry[int](), dir, nil)
+	if err != nil {
+		t.Fatalf("failed to open journal: %v", err)
+	}
+	defer newReg.Close()
+
+	if _, ok := newReg.Get("kajsmentke"); ok {
+		t.Error("expected key kajsmentke to be not found")
+	}
+	if newReg.Len() != 1 {
+		t.Errorf("expected length 1, got %d", newReg.Len())
+	}
+}
+
+func TestJournaledRegistry_TornRecord(t *testing.T) {
+	dir := t.TempDir()
+
+	reg, err := goreg.OpenJournaledRegistry[int](goreg.NewStandardRegistry[int](), dir, nil)
+	if err != nil {
+		t.Fatalf("failed to open journal: %v", err)
+	}
+	reg.Register("kajsmentke", 42)
+	size := reg.Size()
+	reg.Register("kozmeker", 69)
+	reg.Close()
+
+	// Simulate a crash in the middle of writing the last record.
+	logPath := filepath.Join(dir, "journal.log")
+	fi, err := os.Stat(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(logPath, fi.Size()-3); err != nil {
+		t.Fatal(err)
+	}
+
+	newReg, err := goreg.OpenJournaledRegistry[int](goreg.NewStandardRegistry[int](), dir, nil)
+	if err != nil {
+		t.Fatalf("failed to open journal: %v", err)
+	}
+	defer newReg.Close()
+
+	if val, ok := newReg.Get("kajsmentke"); !ok || val != 42 {
+		t.Errorf("expected 42, got %v", val)
+	}
+	if _, ok := newReg.Get("kozmeker"); ok {
+		t.Error("expected key kozmeker to be not found")
+	}
+	if newReg.Size() != size {
+		t.Errorf("expected log size %d, got %d", size, newReg.Size())
+	}
+}
+
+func TestJournaledRegistry_TornTail(t *testing.T) {
+	tests := []struct {
+		name string
+		tail []byte
+	}{
+		{"Zero-filled", make([]byte, 4096)},
+		{"Oversized frame", []byte{0xff, 0xff, 0xff, 0xff, 0x12, 0x34, 0x56, 0x78, '{'}},
+		{"Garbage frame", []byte{0x02, 0x00, 0x00, 0x00, 0x12, 0x34, 0x56, 0x78, '{', '"'}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			reg, err := goreg.OpenJournaledRegistry[int](goreg.NewStandardRegistry[int](), dir, nil)
+			if err != nil {
+				t.Fatalf("failed to open journal: %v", err)
+			}
+			reg.Register("kajsmentke", 42)
+			size := reg.Size()
+			reg.Close()
+
+			logPath := filepath.Join(dir, "journal.log")
+			f, err := os.OpenFile(logPath, os.O_WRONLY|os.O_APPEND, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.Write(test.tail); err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+
+			newReg, err := goreg.OpenJournaledRegistry[int](goreg.NewStandardRegistry[int](), dir, nil)
+			if err != nil {
+				t.Fatalf("failed to open journal: %v", err)
+			}
+			defer newReg.Close()
+
+			if val, ok := newReg.Get("kajsmentke"); !ok || val != 42 {
+				t.Errorf("expected 42, got %v", val)
+			}
+			if newReg.Size() != size {
+				t.Errorf("expected log size %d, got %d", size, newReg.Size())
+			}
+		})
+	}
+}
+
+func TestJournaledRegistry_Corrupt(t *testing.T) {
+	dir := t.TempDir()
+
+	reg, err := goreg.OpenJournaledRegistry[int](goreg.NewStandardRegistry[int](), dir, nil)
+	if err != nil {
+		t.Fatalf("failed to open journal: %v", err)
+	}
+	reg.Register("kajsmentke", 42)
+	reg.Register("kozmeker", 69)
+	reg.Close()
+
+	// Flip a byte in the payload of the first record.
+	logPath := filepath.Join(dir, "journal.log")
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[10] ^= 0xff
+	if err := os.WriteFile(logPath, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = goreg.OpenJournaledRegistry[int](goreg.NewStandardRegistry[int](), dir, nil)
+	if !errors.Is(err, goreg.ErrCorrupt) {
+		t.Errorf("expected ErrCorrupt, got %v", err)
+	}
+}
+
+func TestJournaledRegistry_Compact(t *testing.T) {
+	dir := t.TempDir()
+
+	reg, err := goreg.OpenJournaledRegistry[int](goreg.NewStandardRegistry[int](), dir, &goreg.JournalOptions{CompactThreshold: 100})
+	if err != nil {
+		t.Fatalf("failed to open journal: %v", err)
+	}
+	for i := range 10 {
+		reg.Register("kajsmentke", i)
+	}
+	reg.Register("kozmeker", 69)
+
+	if reg.Size() > 100 {
+		t.Errorf("expected log to be compacted, got size %d", reg.Size())
+	}
+	reg.Close()
+
+	if _, err := os.Stat(filepath.Join(dir, "snapshot.json")); err != nil {
+		t.Errorf("expected snapshot to exist: %v", err)
+	}
+
+	newReg, err := goreg.OpenJournaledRegistry[int](goreg.NewStandardRegistry[int](), dir, nil)
+	if err != nil {
+		t.Fatalf("failed to open journal: %v", err)
+	}
+	defer newReg.Close()
+
+	if val, ok := newReg.Get("kajsmentke"); !ok || val != 9 {
+		t.Errorf("expected 9, got %v", val)
+	}
+	if val, ok := newReg.Get("kozmeker"); !ok || val != 69 {
+		t.Errorf("expected 69, got %v", val)
+	}
+}
+
+func TestJournaledRegistry_Closed(t *testing.T) {
+	var errs []error
+	reg, err := goreg.OpenJournaledRegistry[int](goreg.NewStandardRegistry[int](), t.TempDir(), &goreg.JournalOptions{
+		OnError: func(err error) {
+			errs = append(errs, err)
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to open journal: %v", err)
+	}
+	reg.Register("kajsmentke", 42)
+	reg.Close()
+
+	reg.Register("kozmeker", 69)
+	reg.Unregister("kajsmentke")
+
+	if len(errs) != 2 {
+		t.Errorf("expected 2 errors, got %v", errs)
+	}
+	if _, ok := reg.Get("kozmeker"); ok {
+		t.Error("expected key kozmeker to be not found")
+	}
+	if val, ok := reg.Get("kajsmentke"); !ok || val != 42 {
+		t.Errorf("expected 42, got %v", val)
+	}
+}
+
+func TestJournaledRegistry_CompactCrash(t *testing.T) {
+	dir := t.TempDir()
+
+	reg, err := goreg.OpenJournaledRegistry[int](goreg.NewOrderedRegistry[int](), dir, nil)
+	if err != nil {
+		t.Fatalf("failed to open journal: %v", err)
+	}
+	reg.Register("kajsmentke", 42)
+	reg.Register("kozmeker", 69)
+
+	logPath := filepath.Join(dir, "journal.log")
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.Compact(); err != nil {
+		t.Fatalf("failed to compact: %v", err)
+	}
+	reg.Register("lopata", 7)
+	reg.Close()
+
+	// Simulate a crash after writing the snapshot but before truncating the log.
+	rest, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(logPath, append(data, rest...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	newReg, err := goreg.OpenJournaledRegistry[int](goreg.NewOrderedRegistry[int](), dir, nil)
+	if err != nil {
+		t.Fatalf("failed to open journal: %v", err)
+	}
+	defer newReg.Close()
+
+	expected := "[{kajsmentke 42} {kozmeker 69} {lopata 7}]"
+	if newReg.String() != expected {
+		t.Errorf("expected %s, got %s", expected, newReg.String())
+	}
+}
@@ -0,0 +1,73 @@
+package goreg
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// JSONLCodec is a [Codec] that encodes entries as JSON Lines, with one {"key", "value"} object per line.
+type JSONLCodec[T any] struct{}
+
+// Encode writes the entries to w.
+func (c JSONLCodec[T]) Encode(w io.Writer, entries []Entry[T]) error {
+	enc := json.NewEncoder(w)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return fmt.Errorf("goreg: encoding %q: %w", e.Key, err)
+		}
+	}
+	return nil
+}
+
+// Decode reads entries from r. Every line must hold exactly one entry, blank lines are ignored.
+func (c JSONLCodec[T]) Decode(r io.Reader) ([]Entry[T], error) {
+	var entries []Entry[T]
+	err := c.streamEntries(r, false, func(e Entry[T], _ int64) error {
+		entries = append(entries, e)
+		return nil
+	})
+	return entries, err
+}
+
+func (c JSONLCodec[T]) streamEntries(r io.Reader, strict bool, fn func(e Entry[T], off int64) error) error {
+	br := bufio.NewReader(r)
+	var next int64
+	for i := 0; ; {
+		line, err := br.ReadBytes('\n')
+		if len(line) == 0 && err == io.EOF {
+			return nil
+		}
+		if err != nil && err != io.EOF {
+			return &DecodeError{Index: i, Offset: next, Err: err}
+		}
+
+		off := next
+		next += int64(len(line))
+		trimmed := bytes.TrimLeft(line, " \t\r\n")
+		if len(trimmed) == 0 {
+			continue
+		}
+		off += int64(len(line) - len(trimmed))
+
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		if strict {
+			dec.DisallowUnknownFields()
+		}
+		var e Entry[T]
+		if err := dec.Decode(&e); err != nil {
+			return &DecodeError{Index: i, Offset: off, Err: unexpectedEOF(err)}
+		}
+		// Every line holds exactly one entry.
+		if _, err := dec.Token(); err != io.EOF {
+			return &DecodeError{Index: i, Offset: off, Err: errors.New("unexpected data after the entry")}
+		}
+		if err := fn(e, off); err != nil {
+			return err
+		}
+		i++
+	}
+}
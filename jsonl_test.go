@@ -0,0 +1,55 @@
+package goreg_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/MatusOllah/goreg"
+)
+
+func TestJSONLCodec(t *testing.T) {
+	testCodecRoundTrip(t, goreg.JSONLCodec[int]{})
+}
+
+func TestJSONLCodec_Encode(t *testing.T) {
+	reg := goreg.NewOrderedRegistry[int]()
+	reg.Register("kozmeker", 69)
+	reg.Register("kajsmentke", 42)
+
+	var bf bytes.Buffer
+	if err := goreg.Encode(reg, goreg.JSONLCodec[int]{}, &bf); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+
+	expected := `{"key":"kozmeker","value":69}` + "\n" + `{"key":"kajsmentke","value":42}` + "\n"
+	if bf.String() != expected {
+		t.Errorf("expected %s, got %s", expected, bf.String())
+	}
+}
+
+func TestJSONLCodec_Decode(t *testing.T) {
+	entries, err := goreg.JSONLCodec[int]{}.Decode(strings.NewReader("{\"key\":\"a\",\"value\":1}\n\n{\"key\":\"b\",\"value\":2}\n"))
+	if err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("expected 2 entries, got %d", len(entries))
+	}
+
+	if _, err := (goreg.JSONLCodec[int]{}).Decode(strings.NewReader("{\"key\":\"a\",\"value\":1}\n{\"key\":")); err == nil {
+		t.Error("expected error for truncated input")
+	}
+
+	for _, data := range []string{
+		"{\"key\":\"a\",\"value\":1} {\"key\":\"b\",\"value\":2}\n",
+		"{\"key\":\"a\",\"value\":1}}\n",
+		"{\"key\":\"a\",\n\"value\":1}\n",
+	} {
+		var decErr *goreg.DecodeError
+		if _, err := (goreg.JSONLCodec[int]{}).Decode(strings.NewReader(data)); !errors.As(err, &decErr) {
+			t.Errorf("expected DecodeError for %q, got %v", data, err)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package goreg
+
+import (
+	"fmt"
+	"io"
+)
+
+// DecodeLimits bounds the resources used when decoding untrusted input. A zero field means no limit.
+type DecodeLimits struct {
+	// MaxEntries is the maximum number of entries.
+	MaxEntries int
+
+	// MaxIDLength is the maximum length of an ID in bytes.
+	MaxIDLength int
+
+	// MaxBytes is the maximum size of the input in bytes.
+	MaxBytes int64
+
+	// MaxDepth is the maximum nesting depth of arrays and objects in JSON input,
+	// counting the array or object that holds the entries. It is ignored for other formats.
+	MaxDepth int
+}
+
+// LimitError is returned when the input exceeds one of the [DecodeLimits].
+type LimitError struct {
+	// Limit is the name of the exceeded limit: "entries", "ID length", "bytes" or "depth".
+	Limit string
+
+	// Max is the value of the exceeded limit.
+	Max int64
+}
+
+// Error implements the error interface.
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("goreg: %s limit of %d exceeded", e.Limit, e.Max)
+}
+
+// checkEntry checks the entry at index i with the ID id.
+func (l DecodeLimits) checkEntry(i int, id string) error {
+	if l.MaxEntries > 0 && i >= l.MaxEntries {
+		return &LimitError{Limit: "entries", Max: int64(l.MaxEntries)}
+	}
+	if l.MaxIDLength > 0 && len(id) > l.MaxIDLength {
+		return &LimitError{Limit: "ID length", Max: int64(l.MaxIDLength)}
+	}
+	return nil
+}
+
+// checkData checks the size and, if isJSON is true, the nesting depth of data.
+func (l DecodeLimits) checkData(data []byte, isJSON bool) error {
+	if l.MaxBytes > 0 && int64(len(data)) > l.MaxBytes {
+		return &LimitError{Limit: "bytes", Max: l.MaxBytes}
+	}
+	if isJSON && l.MaxDepth > 0 {
+		var s depthScanner
+		if _, err := s.scan(data, l.MaxDepth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reader wraps r so that reading fails once the input exceeds the size or, if isJSON is true, the depth limit.
+func (l DecodeLimits) reader(r io.Reader, isJSON bool) io.Reader {
+	if l.MaxBytes > 0 {
+		r = &bytesLimitReader{r: r, max: l.MaxBytes}
+	}
+	if isJSON && l.MaxDepth > 0 {
+		r = &depthLimitReader{r: r, max: l.MaxDepth}
+	}
+	return r
+}
+
+// bytesLimitReader is like [io.LimitedReader], but fails with a [*LimitError] instead of returning [io.EOF].
+type bytesLimitReader struct {
+	r   io.Reader
+	n   int64
+	max int64
+}
+
+func (r *bytesLimitReader) Read(p []byte) (int, error) {
+	if r.n >= r.max {
+		// Read a single byte to tell an input of exactly max bytes apart from a longer one.
+		var b [1]byte
+		n, err := r.r.Read(b[:])
+		if n > 0 {
+			return 0, &LimitError{Limit: "bytes", Max: r.max}
+		}
+		return 0, err
+	}
+
+	if rem := r.max - r.n; int64(len(p)) > rem {
+		p = p[:rem]
+	}
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// depthLimitReader fails with a [*LimitError] once the JSON read through it nests deeper than max.
+type depthLimitReader struct {
+	r   io.Reader
+	s   depthScanner
+	max int
+	err error
+}
+
+func (r *depthLimitReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	n, err := r.r.Read(p)
+	if m, serr := r.s.scan(p[:n], r.max); serr != nil {
+		// Hand out the bytes before the offending one, then fail.
+		r.err = serr
+		if m == 0 {
+			return 0, serr
+		}
+		return m, nil
+	}
+	return n, err
+}
+
+// depthScanner tracks the nesting depth of JSON text across calls to scan.
+type depthScanner struct {
+	depth    int
+	inString bool
+	escaped  bool
+}
+
+// scan scans data and returns the number of bytes scanned before the depth went over max.
+func (s *depthScanner) scan(data []byte, max int) (int, error) {
+	for i, c := range data {
+		switch {
+		case s.escaped:
+			s.escaped = false
+		case s.inString:
+			switch c {
+			case '\\':
+				s.escaped = true
+			case '"':
+				s.inString = false
+			}
+		case c == '"':
+			s.inString = true
+		case c == '[' || c == '{':
+			s.depth++
+			if s.depth > max {
+				return i, &LimitError{Limit: "depth", Max: int64(max)}
+			}
+		case c == ']' || c == '}':
+			s.depth--
+		}
+	}
+	return len(data), nil
+}
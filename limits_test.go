@@ -0,0 +1,177 @@
+package goreg_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/MatusOllah/goreg"
+)
+
+func TestDecodeWith_Limits(t *testing.T) {
+	tests := []struct {
+		name   string
+		codec  goreg.Codec[any]
+		data   string
+		limits goreg.DecodeLimits
+		limit  string
+	}{
+		{"Entries", goreg.JSONCodec[any]{}, `{"a":1,"b":2,"c":3}`, goreg.DecodeLimits{MaxEntries: 2}, "entries"},
+		{"IDLength", goreg.JSONCodec[any]{}, `{"a":1,"kajsmentke":2}`, goreg.DecodeLimits{MaxIDLength: 4}, "ID length"},
+		{"Bytes", goreg.JSONCodec[any]{}, `{"a":1,"b":2}`, goreg.DecodeLimits{MaxBytes: 12}, "bytes"},
+		{"Depth", goreg.JSONCodec[any]{}, `{"a":[[1]]}`, goreg.DecodeLimits{MaxDepth: 2}, "depth"},
+		{"DepthJSONL", goreg.JSONLCodec[any]{}, `{"key":"a","value":[1]}`, goreg.DecodeLimits{MaxDepth: 1}, "depth"},
+		{"BytesXML", goreg.XMLCodec[any]{}, `<registry><entry key="a">1</entry></registry>`, goreg.DecodeLimits{MaxBytes: 16}, "bytes"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reg := goreg.NewStandardRegistry[any]()
+			reg.Register("existing", 1)
+
+			err := goreg.DecodeWith(reg, test.codec, strings.NewReader(test.data), &goreg.DecodeOptions[any]{Limits: test.limits})
+			var limitErr *goreg.LimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("expected LimitError, got %v", err)
+			}
+			if limitErr.Limit != test.limit {
+				t.Errorf("expected %s limit, got %s", test.limit, limitErr.Limit)
+			}
+			if reg.Len() != 1 {
+				t.Errorf("expected registry to be untouched, got length %d", reg.Len())
+			}
+		})
+	}
+}
+
+func TestDecodeWith_WithinLimits(t *testing.T) {
+	data := `{"a":[1],"b":"[[[["}`
+	limits := goreg.DecodeLimits{MaxEntries: 2, MaxIDLength: 1, MaxBytes: int64(len(data)), MaxDepth: 2}
+
+	reg := goreg.NewStandardRegistry[any]()
+	if err := goreg.DecodeWith(reg, goreg.JSONCodec[any]{}, strings.NewReader(data), &goreg.DecodeOptions[any]{Limits: limits}); err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	if reg.Len() != 2 {
+		t.Errorf("expected length 2, got %d", reg.Len())
+	}
+}
+
+func TestStandardRegistry_SetDecodeLimits(t *testing.T) {
+	reg := goreg.NewStandardRegistry[int]()
+	reg.Register("existing", 1)
+	reg.SetDecodeLimits(goreg.DecodeLimits{MaxEntries: 1})
+
+	var limitErr *goreg.LimitError
+	if err := json.Unmarshal([]byte(`{"a":1,"b":2}`), reg); !errors.As(err, &limitErr) {
+		t.Errorf("expected LimitError, got %v", err)
+	}
+
+	var bf bytes.Buffer
+	if err := gob.NewEncoder(&bf).Encode(map[string]int{"a": 1, "b": 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.GobDecode(bf.Bytes()); !errors.As(err, &limitErr) {
+		t.Errorf("expected LimitError, got %v", err)
+	}
+
+	if reg.Len() != 1 {
+		t.Errorf("expected registry to be untouched, got length %d", reg.Len())
+	}
+}
+
+func TestOrderedRegistry_SetDecodeLimits(t *testing.T) {
+	reg := goreg.NewOrderedRegistry[int]()
+	reg.Register("existing", 1)
+	reg.SetDecodeLimits(goreg.DecodeLimits{MaxIDLength: 8})
+
+	var limitErr *goreg.LimitError
+	if err := json.Unmarshal([]byte(`{"kajsmentke":42}`), reg); !errors.As(err, &limitErr) {
+		t.Errorf("expected LimitError, got %v", err)
+	}
+
+	other := goreg.NewOrderedRegistry[int]()
+	other.Register("kajsmentke", 42)
+	data, err := other.GobEncode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.GobDecode(data); !errors.As(err, &limitErr) {
+		t.Errorf("expected LimitError, got %v", err)
+	}
+
+	if reg.Len() != 1 {
+		t.Errorf("expected registry to be untouched, got length %d", reg.Len())
+	}
+}
+
+var fuzzLimits = goreg.DecodeLimits{MaxEntries: 8, MaxIDLength: 16, MaxBytes: 1 << 12, MaxDepth: 8}
+
+// checkFuzzLimits checks that a successfully decoded registry is within fuzzLimits.
+func checkFuzzLimits[T any](t *testing.T, reg goreg.Registry[T]) {
+	t.Helper()
+	if reg.Len() > fuzzLimits.MaxEntries {
+		t.Errorf("expected at most %d entries, got %d", fuzzLimits.MaxEntries, reg.Len())
+	}
+	for id := range reg.Iter() {
+		if len(id) > fuzzLimits.MaxIDLength {
+			t.Errorf("expected ID of at most %d bytes, got %q", fuzzLimits.MaxIDLength, id)
+		}
+	}
+}
+
+func FuzzDecodeWith(f *testing.F) {
+	f.Add([]byte(`{"a":1,"b":[2]}`))
+	f.Add([]byte(`[{"key":"a","value":{"x":[1,2]}}]`))
+	f.Add([]byte(`{"a":"\"[[[[[[[[[["}`))
+	f.Add([]byte(`[[[[[[[[[[[[[[[[[[[[`))
+	f.Add([]byte(`null`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, codec := range []goreg.Codec[any]{goreg.JSONCodec[any]{}, goreg.JSONLCodec[any]{}} {
+			reg := goreg.NewStandardRegistry[any]()
+			if err := goreg.DecodeWith(reg, codec, bytes.NewReader(data), &goreg.DecodeOptions[any]{Limits: fuzzLimits}); err != nil {
+				continue
+			}
+			checkFuzzLimits[any](t, reg)
+		}
+	})
+}
+
+func FuzzStandardRegistry_UnmarshalJSON(f *testing.F) {
+	f.Add([]byte(`{"a":1,"b":[2]}`))
+	f.Add([]byte(`{"a":{"b":{"c":{}}}}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		reg := goreg.NewStandardRegistry[any]()
+		reg.SetDecodeLimits(fuzzLimits)
+		if err := reg.UnmarshalJSON(data); err != nil {
+			return
+		}
+		checkFuzzLimits[any](t, reg)
+	})
+}
+
+func FuzzOrderedRegistry_GobDecode(f *testing.F) {
+	reg := goreg.NewOrderedRegistry[string]()
+	reg.Register("kozmeker", "69")
+	reg.Register("kajsmentke", "42")
+	data, err := reg.GobEncode()
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(data)
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		reg := goreg.NewOrderedRegistry[string]()
+		reg.SetDecodeLimits(fuzzLimits)
+		if err := reg.GobDecode(data); err != nil {
+			return
+		}
+		checkFuzzLimits[string](t, reg)
+	})
+}
@@ -0,0 +1,66 @@
+package goreg
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LockStats are lock-contention statistics of a registry.
+type LockStats struct {
+	// Acquisitions is the number of times the lock was acquired, for reading or writing.
+	Acquisitions uint64 `json:"acquisitions"`
+
+	// Contended is the number of acquisitions that had to wait for another holder.
+	Contended uint64 `json:"contended"`
+
+	// Wait is the total time spent waiting in contended acquisitions.
+	Wait time.Duration `json:"wait"`
+}
+
+// statsMutex is a [sync.RWMutex] that counts how often it is contended.
+type statsMutex struct {
+	mu sync.RWMutex
+
+	acquisitions atomic.Uint64
+	contended    atomic.Uint64
+	wait         atomic.Int64
+}
+
+func (m *statsMutex) Lock() {
+	m.acquisitions.Add(1)
+	if m.mu.TryLock() {
+		return
+	}
+	start := time.Now()
+	m.mu.Lock()
+	m.contended.Add(1)
+	m.wait.Add(int64(time.Since(start)))
+}
+
+func (m *statsMutex) Unlock() {
+	m.mu.Unlock()
+}
+
+func (m *statsMutex) RLock() {
+	m.acquisitions.Add(1)
+	if m.mu.TryRLock() {
+		return
+	}
+	start := time.Now()
+	m.mu.RLock()
+	m.contended.Add(1)
+	m.wait.Add(int64(time.Since(start)))
+}
+
+func (m *statsMutex) RUnlock() {
+	m.mu.RUnlock()
+}
+
+func (m *statsMutex) stats() LockStats {
+	return LockStats{
+		Acquisitions: m.acquisitions.Load(),
+		Contended:    m.contended.Load(),
+		Wait:         time.Duration(m.wait.Load()),
+	}
+}
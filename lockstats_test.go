@@ -0,0 +1,37 @@
+package goreg_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/MatusOllah/goreg"
+)
+
+func TestLockStats(t *testing.T) {
+	reg := goreg.NewOrderedRegistry[int]()
+	reg.Register("kozmeker", 69)
+	reg.Get("kozmeker")
+	if stats := reg.LockStats(); stats.Acquisitions != 2 || stats.Contended != 0 || stats.Wait != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	// Writers waiting on an iteration are contended.
+	var wg sync.WaitGroup
+	for id := range reg.Iter() {
+		_ = id
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				reg.Register("kajsmentke", 42)
+			}()
+		}
+		waitFor(t, func() bool { return reg.LockStats().Acquisitions >= 7 })
+	}
+	wg.Wait()
+
+	stats := reg.LockStats()
+	if stats.Contended != 4 || stats.Wait <= 0 {
+		t.Errorf("expected 4 contended acquisitions, got %+v", stats)
+	}
+}
@@ -0,0 +1,268 @@
+package goreg
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// The mapped registry format consists of a header, a fixed-size index sorted by key and a data section:
+//
+//	header: magic "GRMP" | version uint32 | count uint64
+//	index:  count * (keyOff uint64 | valOff uint64 | keyLen uint32 | valLen uint32)
+//	data:   keys and JSON-encoded values
+//
+// All integers are little-endian and all offsets are relative to the start of the file.
+const (
+	mappedMagic      = "GRMP"
+	mappedVersion    = 1
+	mappedHeaderSize = 16
+	mappedIndexSize  = 24
+)
+
+// MappedRegistry is a read-only registry backed by a memory-mapped file written by [WriteMapped].
+//
+// Opening a MappedRegistry does not decode anything. Keys are looked up with a binary search over the index
+// and values are decoded on every access, so huge registries can be opened instantly without using much heap.
+// Objects are ordered by ID.
+//
+// On platforms without mmap support, the file is read into memory instead.
+type MappedRegistry[T any] struct {
+	data  []byte
+	count int
+	close func() error
+}
+
+// WriteMapped writes the objects in reg to w in the format read by [MappedRegistry]. Values are encoded as JSON.
+func WriteMapped[T any](w io.Writer, reg Registry[T]) error {
+	type entry struct {
+		key string
+		val []byte
+	}
+
+	var entries []entry
+	for id, obj := range reg.Iter() {
+		val, err := json.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("goreg: encoding %q: %w", id, err)
+		}
+		entries = append(entries, entry{key: id, val: val})
+	}
+	slices.SortStableFunc(entries, func(a, b entry) int {
+		return strings.Compare(a.key, b.key)
+	})
+	entries = slices.CompactFunc(entries, func(a, b entry) bool {
+		return a.key == b.key
+	})
+
+	bw := bufio.NewWriter(w)
+
+	var hdr [mappedHeaderSize]byte
+	copy(hdr[0:4], mappedMagic)
+	binary.LittleEndian.PutUint32(hdr[4:8], mappedVersion)
+	binary.LittleEndian.PutUint64(hdr[8:16], uint64(len(entries)))
+	bw.Write(hdr[:])
+
+	off := uint64(mappedHeaderSize + len(entries)*mappedIndexSize)
+	for _, e := range entries {
+		if uint64(len(e.key)) > math.MaxUint32 || uint64(len(e.val)) > math.MaxUint32 {
+			return fmt.Errorf("goreg: entry %q is too large", e.key)
+		}
+
+		var idx [mappedIndexSize]byte
+		binary.LittleEndian.PutUint64(idx[0:8], off)
+		binary.LittleEndian.PutUint64(idx[8:16], off+uint64(len(e.key)))
+		binary.LittleEndian.PutUint32(idx[16:20], uint32(len(e.key)))
+		binary.LittleEndian.PutUint32(idx[20:24], uint32(len(e.val)))
+		bw.Write(idx[:])
+
+		off += uint64(len(e.key) + len(e.val))
+	}
+
+	for _, e := range entries {
+		bw.WriteString(e.key)
+		bw.Write(e.val)
+	}
+
+	return bw.Flush()
+}
+
+// OpenMappedRegistry opens a file written by [WriteMapped] as a [MappedRegistry].
+// The returned registry must be closed with Close.
+func OpenMappedRegistry[T any](path string) (*MappedRegistry[T], error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("goreg: opening %s: %w", path, err)
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("goreg: opening %s: %w", path, err)
+	}
+	if fi.Size() > math.MaxInt {
+		return nil, fmt.Errorf("goreg: opening %s: file too large", path)
+	}
+
+	data, unmap, err := mmapFile(f, int(fi.Size()))
+	if err != nil {
+		return nil, fmt.Errorf("goreg: mapping %s: %w", path, err)
+	}
+
+	r := &MappedRegistry[T]{data: data, close: unmap}
+	if err := r.validate(); err != nil {
+		unmap()
+		return nil, fmt.Errorf("goreg: opening %s: %w: %w", path, ErrCorrupt, err)
+	}
+
+	return r, nil
+}
+
+func (r *MappedRegistry[T]) validate() error {
+	if len(r.data) < mappedHeaderSize || string(r.data[0:4]) != mappedMagic {
+		return fmt.Errorf("not a mapped registry file")
+	}
+	if v := binary.LittleEndian.Uint32(r.data[4:8]); v != mappedVersion {
+		return fmt.Errorf("unsupported version %d", v)
+	}
+
+	count := binary.LittleEndian.Uint64(r.data[8:16])
+	if count > uint64(len(r.data)-mappedHeaderSize)/mappedIndexSize {
+		return fmt.Errorf("index out of bounds")
+	}
+	r.count = int(count)
+
+	for i := range r.count {
+		keyOff, valOff, keyLen, valLen := r.index(i)
+		size := uint64(len(r.data))
+		if keyOff > size || keyLen > size-keyOff || valOff > size || valLen > size-valOff {
+			return fmt.Errorf("entry %d out of bounds", i)
+		}
+	}
+
+	return nil
+}
+
+func (r *MappedRegistry[T]) index(i int) (keyOff, valOff, keyLen, valLen uint64) {
+	idx := r.data[mappedHeaderSize+i*mappedIndexSize:]
+	return binary.LittleEndian.Uint64(idx[0:8]),
+		binary.LittleEndian.Uint64(idx[8:16]),
+		uint64(binary.LittleEndian.Uint32(idx[16:20])),
+		uint64(binary.LittleEndian.Uint32(idx[20:24]))
+}
+
+func (r *MappedRegistry[T]) key(i int) []byte {
+	keyOff, _, keyLen, _ := r.index(i)
+	return r.data[keyOff : keyOff+keyLen]
+}
+
+func (r *MappedRegistry[T]) value(i int) (obj T, ok bool) {
+	_, valOff, _, valLen := r.index(i)
+	if err := json.Unmarshal(r.data[valOff:valOff+valLen], &obj); err != nil {
+		slog.Error("*goreg.MappedRegistry: failed to decode object", "id", string(r.key(i)), "err", err)
+		return obj, false
+	}
+	return obj, true
+}
+
+// Register does nothing and logs error, because the registry is read-only.
+func (r *MappedRegistry[T]) Register(id string, obj T) {
+	slog.Error("*goreg.MappedRegistry: registry is read-only", "id", id)
+}
+
+// Unregister does nothing and logs error, because the registry is read-only.
+func (r *MappedRegistry[T]) Unregister(id string) {
+	slog.Error("*goreg.MappedRegistry: registry is read-only", "id", id)
+}
+
+// Get returns the object under the ID.
+func (r *MappedRegistry[T]) Get(id string) (obj T, ok bool) {
+	key := []byte(id)
+	i := sort.Search(r.count, func(i int) bool {
+		return bytes.Compare(r.key(i), key) >= 0
+	})
+	if i >= r.count || !bytes.Equal(r.key(i), key) {
+		return obj, false
+	}
+
+	return r.value(i)
+}
+
+// MustGet returns the object under the ID and logs error if not found.
+func (r *MappedRegistry[T]) MustGet(id string) T {
+	obj, ok := r.Get(id)
+	if !ok {
+		slog.Error("*goreg.MappedRegistry: object not found", "id", id)
+	}
+	return obj
+}
+
+// GetIndex returns the object under the index. Objects are ordered by ID.
+func (r *MappedRegistry[T]) GetIndex(i int) (obj T, ok bool) {
+	if i < 0 || i >= r.count {
+		return obj, false
+	}
+
+	return r.value(i)
+}
+
+// MustGetIndex returns the object under the index and logs error if not found.
+func (r *MappedRegistry[T]) MustGetIndex(i int) T {
+	obj, ok := r.GetIndex(i)
+	if !ok {
+		slog.Error("*goreg.MappedRegistry: object by index not found", "i", i)
+	}
+	return obj
+}
+
+// Len returns the number of items in the registry.
+func (r *MappedRegistry[T]) Len() int {
+	return r.count
+}
+
+// Reset does nothing and logs error, because the registry is read-only.
+func (r *MappedRegistry[T]) Reset() {
+	slog.Error("*goreg.MappedRegistry: registry is read-only")
+}
+
+// Iter returns an iterator over key-value pairs ordered by ID. See the [iter] package documentation for more details.
+// Objects that fail to decode are skipped.
+func (r *MappedRegistry[T]) Iter() iter.Seq2[string, T] {
+	return func(yield func(string, T) bool) {
+		for i := range r.count {
+			obj, ok := r.value(i)
+			if !ok {
+				continue
+			}
+			if !yield(string(r.key(i)), obj) {
+				return
+			}
+		}
+	}
+}
+
+// String returns a string representation of the registry.
+func (r *MappedRegistry[T]) String() string {
+	return fmt.Sprintf("%v", Entries[T](r))
+}
+
+// Close unmaps the file. The registry must not be used after Close.
+func (r *MappedRegistry[T]) Close() error {
+	if r.close == nil {
+		return nil
+	}
+
+	err := r.close()
+	r.data, r.count, r.close = nil, 0, nil
+	return err
+}
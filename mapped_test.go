@@ -0,0 +1,145 @@
+package goreg_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MatusOllah/goreg"
+)
+
+func writeMappedFile(t *testing.T, reg goreg.Registry[int]) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "reg.bin")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if err := goreg.WriteMapped(f, reg); err != nil {
+		t.Fatalf("failed to write mapped registry: %v", err)
+	}
+
+	return path
+}
+
+func TestMappedRegistry_Get(t *testing.T) {
+	src := goreg.NewStandardRegistry[int]()
+	src.Register("kozmeker", 69)
+	src.Register("kajsmentke", 42)
+	src.Register("a", 1)
+
+	reg, err := goreg.OpenMappedRegistry[int](writeMappedFile(t, src))
+	if err != nil {
+		t.Fatalf("failed to open mapped registry: %v", err)
+	}
+	defer reg.Close()
+
+	if reg.Len() != 3 {
+		t.Errorf("expected length 3, got %d", reg.Len())
+	}
+	if val, ok := reg.Get("kajsmentke"); !ok || val != 42 {
+		t.Errorf("expected 42, got %v", val)
+	}
+	if val, ok := reg.Get("kozmeker"); !ok || val != 69 {
+		t.Errorf("expected 69, got %v", val)
+	}
+	if _, ok := reg.Get("invalid"); ok {
+		t.Error("expected key to be not found")
+	}
+	if !goreg.Equal[int](src, reg) {
+		t.Error("expected equal, but not equal")
+	}
+}
+
+func TestMappedRegistry_GetIndex(t *testing.T) {
+	src := goreg.NewStandardRegistry[int]()
+	src.Register("kozmeker", 69)
+	src.Register("kajsmentke", 42)
+
+	reg, err := goreg.OpenMappedRegistry[int](writeMappedFile(t, src))
+	if err != nil {
+		t.Fatalf("failed to open mapped registry: %v", err)
+	}
+	defer reg.Close()
+
+	tests := []struct {
+		name        string
+		index       int
+		expectOK    bool
+		expectValue int
+	}{
+		{"Valid index 0", 0, true, 42},
+		{"Valid index 1", 1, true, 69},
+		{"Invalid negative index", -1, false, 0},
+		{"Invalid out-of-bounds index", 2, false, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			val, ok := reg.GetIndex(test.index)
+			if ok != test.expectOK {
+				t.Errorf("expected ok=%v, got %v", test.expectOK, ok)
+			}
+			if ok && val != test.expectValue {
+				t.Errorf("expected value %v, got %v", test.expectValue, val)
+			}
+		})
+	}
+}
+
+func TestMappedRegistry_Iter(t *testing.T) {
+	src := goreg.NewStandardRegistry[int]()
+	src.Register("kozmeker", 69)
+	src.Register("kajsmentke", 42)
+
+	reg, err := goreg.OpenMappedRegistry[int](writeMappedFile(t, src))
+	if err != nil {
+		t.Fatalf("failed to open mapped registry: %v", err)
+	}
+	defer reg.Close()
+
+	var ids []string
+	for id := range reg.Iter() {
+		ids = append(ids, id)
+	}
+	if len(ids) != 2 || ids[0] != "kajsmentke" || ids[1] != "kozmeker" {
+		t.Errorf("expected [kajsmentke kozmeker], got %v", ids)
+	}
+}
+
+func TestMappedRegistry_Empty(t *testing.T) {
+	reg, err := goreg.OpenMappedRegistry[int](writeMappedFile(t, goreg.NewStandardRegistry[int]()))
+	if err != nil {
+		t.Fatalf("failed to open mapped registry: %v", err)
+	}
+	defer reg.Close()
+
+	if reg.Len() != 0 {
+		t.Errorf("expected length 0, got %d", reg.Len())
+	}
+	if _, ok := reg.Get("invalid"); ok {
+		t.Error("expected key to be not found")
+	}
+}
+
+func TestMappedRegistry_Corrupt(t *testing.T) {
+	src := goreg.NewStandardRegistry[int]()
+	src.Register("kajsmentke", 42)
+	path := writeMappedFile(t, src)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data[:len(data)-4], 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := goreg.OpenMappedRegistry[int](path); !errors.Is(err, goreg.ErrCorrupt) {
+		t.Errorf("expected ErrCorrupt, got %v", err)
+	}
+}
@@ -0,0 +1,236 @@
+package goreg
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ErrNameTaken is returned by [Publish] when a registry is already published under the name.
+var ErrNameTaken = errors.New("goreg: name already taken")
+
+// NamedRegistry is a registry published with [Publish], viewed without its type parameter.
+// It is meant for introspection tools.
+type NamedRegistry interface {
+	// Name returns the name the registry is published under.
+	Name() string
+
+	// Type returns the name of the type of the registry, e.g. "*goreg.StandardRegistry[int]".
+	Type() string
+
+	// ValueType returns the name of the type of the values, e.g. "int".
+	ValueType() string
+
+	// Len returns the number of items in the registry.
+	Len() int
+
+	// Get returns the object under the ID.
+	Get(id string) (obj any, ok bool)
+
+	// Entries returns the entries of the registry, sorted by ID.
+	Entries() []Entry[any]
+
+	// IDs returns the IDs in the registry, sorted and without duplicates. The slice must not be modified.
+	// For registries that number their mutations, like [FeedRegistry], it is cached until the next mutation.
+	IDs() []string
+
+	// LockStats returns the lock-contention statistics of the registry.
+	// ok is false if the registry doesn't keep any, like most wrappers.
+	LockStats() (stats LockStats, ok bool)
+
+	// Set decodes a JSON value and registers it under the ID.
+	Set(id string, data []byte) error
+
+	// Delete unregisters an object under the ID.
+	Delete(id string)
+
+	// Feed returns the change feed of the registry. ok is false if the registry isn't a [FeedRegistry].
+	Feed() (feed NamedFeed, ok bool)
+}
+
+// NamedFeed is the change feed of a [NamedRegistry], viewed without its type parameter. See [FeedRegistry].
+type NamedFeed interface {
+	// Seq returns the sequence number of the last mutation.
+	Seq() uint64
+
+	// ChangesSince returns the changes after the sequence number seq, oldest first.
+	ChangesSince(seq uint64) ([]Change[any], error)
+
+	// Changed returns a channel that is closed on the next mutation.
+	Changed() <-chan struct{}
+}
+
+type namedRegistry[T any] struct {
+	name string
+	reg  Registry[T]
+
+	mu     sync.Mutex
+	ids    []string // sorted IDs as of idsSeq
+	idsSeq uint64
+	cached bool
+}
+
+func (n *namedRegistry[T]) Name() string {
+	return n.name
+}
+
+func (n *namedRegistry[T]) Type() string {
+	return typeName(reflect.TypeOf(n.reg))
+}
+
+func (n *namedRegistry[T]) ValueType() string {
+	return typeName(reflect.TypeFor[T]())
+}
+
+func (n *namedRegistry[T]) Len() int {
+	return n.reg.Len()
+}
+
+func (n *namedRegistry[T]) Get(id string) (any, bool) {
+	return n.reg.Get(id)
+}
+
+func (n *namedRegistry[T]) Entries() []Entry[any] {
+	entries := make([]Entry[any], 0, n.reg.Len())
+	for id, obj := range n.reg.Iter() {
+		entries = append(entries, Entry[any]{Key: id, Value: obj})
+	}
+	slices.SortFunc(entries, func(a, b Entry[any]) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return entries
+}
+
+func (n *namedRegistry[T]) IDs() []string {
+	s, ok := n.reg.(seqRegistry)
+	if !ok {
+		return n.sortedIDs()
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	// The sequence number is read first, so a mutation made while collecting the IDs
+	// only causes them to be collected again next time.
+	seq := s.Seq()
+	if !n.cached || n.idsSeq != seq {
+		n.ids, n.idsSeq, n.cached = n.sortedIDs(), seq, true
+	}
+	return n.ids
+}
+
+func (n *namedRegistry[T]) sortedIDs() []string {
+	ids := make([]string, 0, n.reg.Len())
+	for id := range n.reg.Iter() {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
+
+func (n *namedRegistry[T]) LockStats() (LockStats, bool) {
+	if r, ok := n.reg.(interface{ LockStats() LockStats }); ok {
+		return r.LockStats(), true
+	}
+	return LockStats{}, false
+}
+
+func (n *namedRegistry[T]) Set(id string, data []byte) error {
+	var obj T
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	n.reg.Register(id, obj)
+	return nil
+}
+
+func (n *namedRegistry[T]) Delete(id string) {
+	n.reg.Unregister(id)
+}
+
+func (n *namedRegistry[T]) Feed() (NamedFeed, bool) {
+	if feed, ok := n.reg.(*FeedRegistry[T]); ok {
+		return namedFeed[T]{feed}, true
+	}
+	return nil, false
+}
+
+type namedFeed[T any] struct {
+	*FeedRegistry[T]
+}
+
+func (f namedFeed[T]) ChangesSince(seq uint64) ([]Change[any], error) {
+	changes, err := f.FeedRegistry.ChangesSince(seq)
+	if err != nil {
+		return nil, err
+	}
+	anyChanges := make([]Change[any], len(changes))
+	for i, c := range changes {
+		anyChanges[i] = Change[any]{Seq: c.Seq, Op: c.Op, ID: c.ID, Value: c.Value}
+		if c.Op != OpRegister {
+			anyChanges[i].Value = nil
+		}
+	}
+	return anyChanges, nil
+}
+
+// typeName returns the name of t, qualified with the package name like in Go code.
+func typeName(t reflect.Type) string {
+	if t == nil {
+		return "<nil>"
+	}
+	return t.String()
+}
+
+var (
+	namedMu sync.RWMutex
+	named   = make(map[string]NamedRegistry)
+)
+
+// Publish publishes reg under the name, so that introspection tools like the goreg/debug package can find it.
+// It returns [ErrNameTaken] if the name is already taken.
+func Publish[T any](name string, reg Registry[T]) error {
+	namedMu.Lock()
+	defer namedMu.Unlock()
+
+	if _, ok := named[name]; ok {
+		return fmt.Errorf("%w: %q", ErrNameTaken, name)
+	}
+	named[name] = &namedRegistry[T]{name: name, reg: reg}
+	return nil
+}
+
+// Unpublish removes the registry published under the name.
+func Unpublish(name string) {
+	namedMu.Lock()
+	defer namedMu.Unlock()
+	delete(named, name)
+}
+
+// Lookup returns the registry published under the name.
+func Lookup(name string) (NamedRegistry, bool) {
+	namedMu.RLock()
+	defer namedMu.RUnlock()
+	n, ok := named[name]
+	return n, ok
+}
+
+// Published returns all published registries, sorted by name.
+func Published() []NamedRegistry {
+	namedMu.RLock()
+	defer namedMu.RUnlock()
+
+	regs := make([]NamedRegistry, 0, len(named))
+	for _, n := range named {
+		regs = append(regs, n)
+	}
+	slices.SortFunc(regs, func(a, b NamedRegistry) int {
+		return cmp.Compare(a.Name(), b.Name())
+	})
+	return regs
+}
@@ -0,0 +1,122 @@
+package goreg_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/MatusOllah/goreg"
+)
+
+func TestPublish(t *testing.T) {
+	reg := goreg.NewStandardRegistry[int]()
+	reg.Register("kozmeker", 69)
+	reg.Register("kajsmentke", 42)
+
+	if err := goreg.Publish[int]("test/publish", reg); err != nil {
+		t.Fatal(err)
+	}
+	defer goreg.Unpublish("test/publish")
+	if err := goreg.Publish[int]("test/publish", reg); !errors.Is(err, goreg.ErrNameTaken) {
+		t.Errorf("expected ErrNameTaken, got %v", err)
+	}
+
+	named, ok := goreg.Lookup("test/publish")
+	if !ok {
+		t.Fatal("expected registry to be published")
+	}
+	if named.Type() != "*goreg.StandardRegistry[int]" || named.ValueType() != "int" {
+		t.Errorf("unexpected types %s, %s", named.Type(), named.ValueType())
+	}
+	if named.Len() != 2 {
+		t.Errorf("expected length 2, got %d", named.Len())
+	}
+	if val, ok := named.Get("kozmeker"); !ok || val != 69 {
+		t.Errorf("expected 69, got %v", val)
+	}
+	entries := named.Entries()
+	if len(entries) != 2 || entries[0].Key != "kajsmentke" || entries[1].Key != "kozmeker" {
+		t.Errorf("expected sorted entries, got %v", entries)
+	}
+	if _, ok := named.LockStats(); !ok {
+		t.Error("expected lock stats for a StandardRegistry")
+	}
+
+	found := false
+	for _, n := range goreg.Published() {
+		found = found || n.Name() == "test/publish"
+	}
+	if !found {
+		t.Error("expected registry in Published")
+	}
+
+	goreg.Unpublish("test/publish")
+	if _, ok := goreg.Lookup("test/publish"); ok {
+		t.Error("expected registry to be unpublished")
+	}
+}
+
+func TestNamedRegistry_Feed(t *testing.T) {
+	feed := goreg.NewFeedRegistry[int](goreg.NewStandardRegistry[int](), nil)
+	if err := goreg.Publish[int]("test/feed", feed); err != nil {
+		t.Fatal(err)
+	}
+	defer goreg.Unpublish("test/feed")
+	named, _ := goreg.Lookup("test/feed")
+
+	if err := named.Set("kozmeker", []byte("69")); err != nil {
+		t.Fatal(err)
+	}
+	if err := named.Set("kozmeker", []byte(`"nope"`)); err == nil {
+		t.Error("expected an error for a value of the wrong type")
+	}
+	named.Delete("kozmeker")
+
+	nf, ok := named.Feed()
+	if !ok {
+		t.Fatal("expected a feed")
+	}
+	changes, err := nf.ChangesSince(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []goreg.Change[any]{
+		{Seq: 1, Op: goreg.OpRegister, ID: "kozmeker", Value: 69},
+		{Seq: 2, Op: goreg.OpUnregister, ID: "kozmeker"},
+	}
+	if !slices.Equal(changes, expected) {
+		t.Errorf("expected %v, got %v", expected, changes)
+	}
+
+	goreg.Publish[int]("test/standard", goreg.NewStandardRegistry[int]())
+	defer goreg.Unpublish("test/standard")
+	if named, _ := goreg.Lookup("test/standard"); named != nil {
+		if _, ok := named.Feed(); ok {
+			t.Error("expected no feed for a StandardRegistry")
+		}
+	}
+}
+
+func TestNamedRegistry_IDs(t *testing.T) {
+	feed := goreg.NewFeedRegistry[int](goreg.NewStandardRegistry[int](), nil)
+	feed.Register("kozmeker", 69)
+	feed.Register("kajsmentke", 42)
+	if err := goreg.Publish[int]("test/ids", feed); err != nil {
+		t.Fatal(err)
+	}
+	defer goreg.Unpublish("test/ids")
+	named, _ := goreg.Lookup("test/ids")
+
+	ids := named.IDs()
+	if expected := []string{"kajsmentke", "kozmeker"}; !slices.Equal(ids, expected) {
+		t.Errorf("expected %v, got %v", expected, ids)
+	}
+	if again := named.IDs(); &again[0] != &ids[0] {
+		t.Error("expected the IDs to be cached until the next mutation")
+	}
+
+	feed.Register("a", 0)
+	if expected := []string{"a", "kajsmentke", "kozmeker"}; !slices.Equal(named.IDs(), expected) {
+		t.Errorf("expected %v, got %v", expected, named.IDs())
+	}
+}
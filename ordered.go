@@ -9,35 +9,65 @@ import (
 	"log/slog"
 	"slices"
 	"strings"
-	"sync"
 )
 
-type kvPair[T any] struct {
-	Key   string `json:"key"`
-	Value T      `json:"value"`
-}
+// JSONFormat is a JSON encoding of an [OrderedRegistry].
+type JSONFormat int
+
+const (
+	// JSONArray encodes the registry as an array of {"key", "value"} objects. This is the default.
+	JSONArray JSONFormat = iota
+
+	// JSONObject encodes the registry as a plain object, like [StandardRegistry], keeping the order of the keys.
+	JSONObject
+)
 
 // OrderedRegistry is a ordered registry. It uses a slice under the hood.
 type OrderedRegistry[T any] struct {
-	objs []kvPair[T]
-	mu   sync.RWMutex
+	objs       []Entry[T]
+	index      []int // indices into objs, sorted by key and then by index
+	jsonFormat JSONFormat
+	limits     DecodeLimits
+	mu         statsMutex
 }
 
 // NewOrderedRegistry creates a new [OrderedRegistry].
 func NewOrderedRegistry[T any]() *OrderedRegistry[T] {
-	return &OrderedRegistry[T]{objs: []kvPair[T]{}}
+	return &OrderedRegistry[T]{objs: []Entry[T]{}}
 }
 
 // Register registers an object under the ID.
 func (r *OrderedRegistry[T]) Register(id string, obj T) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.objs = append(r.objs, kvPair[T]{Key: id, Value: obj})
+
+	// The new object has the highest index, so it goes after all objects with the same key.
+	j, _ := slices.BinarySearchFunc(r.index, id, func(i int, id string) int {
+		if r.objs[i].Key <= id {
+			return -1
+		}
+		return 1
+	})
+	r.index = slices.Insert(r.index, j, len(r.objs))
+	r.objs = append(r.objs, Entry[T]{Key: id, Value: obj})
 }
 
-func (r *OrderedRegistry[T]) findIndex(id string) (i int, ok bool) {
-	return slices.BinarySearchFunc(r.objs, kvPair[T]{Key: id}, func(a, b kvPair[T]) int {
-		return strings.Compare(a.Key, b.Key)
+// findIndex returns the position in r.index of the first registered object under the ID.
+// The objects are kept in registration order, so this binary-searches the sorted index instead.
+func (r *OrderedRegistry[T]) findIndex(id string) (j int, ok bool) {
+	return slices.BinarySearchFunc(r.index, id, func(i int, id string) int {
+		return strings.Compare(r.objs[i].Key, id)
+	})
+}
+
+// reindex rebuilds the sorted index from the objects.
+func (r *OrderedRegistry[T]) reindex() {
+	r.index = make([]int, len(r.objs))
+	for i := range r.index {
+		r.index[i] = i
+	}
+	slices.SortStableFunc(r.index, func(a, b int) int {
+		return strings.Compare(r.objs[a].Key, r.objs[b].Key)
 	})
 }
 
@@ -46,12 +76,19 @@ func (r *OrderedRegistry[T]) Unregister(id string) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	i, ok := r.findIndex(id)
+	j, ok := r.findIndex(id)
 	if !ok {
 		return
 	}
 
+	i := r.index[j]
 	r.objs = slices.Delete(r.objs, i, i+1)
+	r.index = slices.Delete(r.index, j, j+1)
+	for k, n := range r.index {
+		if n > i {
+			r.index[k] = n - 1
+		}
+	}
 }
 
 // Get returns the object under the ID.
@@ -59,12 +96,12 @@ func (r *OrderedRegistry[T]) Get(id string) (obj T, ok bool) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	i, ok := r.findIndex(id)
+	j, ok := r.findIndex(id)
 	if !ok {
 		return
 	}
 
-	return r.objs[i].Value, ok
+	return r.objs[r.index[j]].Value, ok
 }
 
 // MustGet returns the object under the ID and logs error if not found.
@@ -109,7 +146,8 @@ func (r *OrderedRegistry[T]) Len() int {
 func (r *OrderedRegistry[T]) Reset() {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.objs = []kvPair[T]{}
+	r.objs = []Entry[T]{}
+	r.index = nil
 }
 
 // Iter returns an iterator over key-value pairs. See the [iter] package documentation for more details.
@@ -128,23 +166,57 @@ func (r *OrderedRegistry[T]) Iter() iter.Seq2[string, T] {
 	}
 }
 
+// LockStats returns the lock-contention statistics of the registry.
+func (r *OrderedRegistry[T]) LockStats() LockStats {
+	return r.mu.stats()
+}
+
 // String returns a string representation of the registry.
 func (r *OrderedRegistry[T]) String() string {
 	return fmt.Sprintf("%v", r.objs)
 }
 
+// SetDecodeLimits sets the limits enforced by UnmarshalJSON and GobDecode.
+func (r *OrderedRegistry[T]) SetDecodeLimits(limits DecodeLimits) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.limits = limits
+}
+
+// SetJSONFormat sets the format used by MarshalJSON. UnmarshalJSON accepts both formats.
+func (r *OrderedRegistry[T]) SetJSONFormat(format JSONFormat) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jsonFormat = format
+}
+
 // MarshalJSON implements the [encoding/json.Marshaler] interface.
 func (r *OrderedRegistry[T]) MarshalJSON() ([]byte, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
+
+	if r.jsonFormat == JSONObject {
+		return marshalJSONObject(r.objs)
+	}
 	return json.Marshal(r.objs)
 }
 
 // UnmarshalJSON implements the [encoding/json.Unmarshaler] interface.
+// The decoded objects replace the contents of the registry, keeping duplicate IDs.
+// Use [DecodeWith] to pick the load semantics explicitly.
+// If decoding fails, the registry is left untouched.
 func (r *OrderedRegistry[T]) UnmarshalJSON(data []byte) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	return json.Unmarshal(data, &r.objs)
+
+	if err := r.limits.checkData(data, true); err != nil {
+		return err
+	}
+	entries, err := decodeJSON[T](bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	return r.replace(entries)
 }
 
 // GobEncode implements the [encoding/gob.GobEncoder] interface.
@@ -161,8 +233,33 @@ func (r *OrderedRegistry[T]) GobEncode() ([]byte, error) {
 }
 
 // GobDecode implements the [encoding/gob.GobDecoder] interface.
+// If decoding fails, the registry is left untouched.
 func (r *OrderedRegistry[T]) GobDecode(data []byte) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	return gob.NewDecoder(bytes.NewReader(data)).Decode(&r.objs)
+
+	if err := r.limits.checkData(data, false); err != nil {
+		return err
+	}
+	var entries []Entry[T]
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&entries); err != nil {
+		return err
+	}
+	return r.replace(entries)
+}
+
+// replace checks the decoded entries against the decode limits and replaces the contents of the registry with them.
+func (r *OrderedRegistry[T]) replace(entries []Entry[T]) error {
+	for i, e := range entries {
+		if err := r.limits.checkEntry(i, e.Key); err != nil {
+			return err
+		}
+	}
+
+	if entries == nil {
+		entries = []Entry[T]{}
+	}
+	r.objs = entries
+	r.reindex()
+	return nil
 }
@@ -73,6 +73,35 @@ func TestOrderedRegistry_Unregister(t *testing.T) {
 	}
 }
 
+func TestOrderedRegistry_UnsortedKeys(t *testing.T) {
+	reg := goreg.NewOrderedRegistry[int]()
+	reg.Register("kozmeker", 69)
+	reg.Register("kajsmentke", 42)
+	reg.Register("lopata", 7)
+	reg.Register("kajsmentke", 43)
+	reg.Register("bager", 1)
+
+	if val, ok := reg.Get("kajsmentke"); !ok || val != 42 {
+		t.Errorf("expected the first kajsmentke 42, got %v", val)
+	}
+
+	reg.Unregister("kozmeker")
+	reg.Unregister("kajsmentke")
+
+	want := map[string]int{"kajsmentke": 43, "lopata": 7, "bager": 1}
+	for id, v := range want {
+		if val, ok := reg.Get(id); !ok || val != v {
+			t.Errorf("expected %s to be %d, got %v", id, v, val)
+		}
+	}
+	if _, ok := reg.Get("kozmeker"); ok {
+		t.Error("expected key kozmeker to be not found")
+	}
+	if val, _ := reg.GetIndex(0); val != 7 {
+		t.Errorf("expected lopata at index 0, got %v", val)
+	}
+}
+
 func TestOrderedRegistry_Len(t *testing.T) {
 	reg := goreg.NewOrderedRegistry[int]()
 
@@ -169,6 +198,43 @@ func TestOrderedRegistry_JSONCodec(t *testing.T) {
 	}
 }
 
+func TestOrderedRegistry_JSONObject(t *testing.T) {
+	reg := goreg.NewOrderedRegistry[int]()
+	reg.SetJSONFormat(goreg.JSONObject)
+	reg.Register("kozmeker", 69)
+	reg.Register("kajsmentke", 42)
+
+	data, err := reg.MarshalJSON()
+	if err != nil {
+		t.Errorf("failed to marshal JSON: %v", err)
+	}
+
+	expected := `{"kozmeker":69,"kajsmentke":42}`
+	if string(data) != expected {
+		t.Errorf("expected %s, got %s", expected, data)
+	}
+
+	newReg := goreg.NewOrderedRegistry[int]()
+	if err := newReg.UnmarshalJSON(data); err != nil {
+		t.Errorf("failed to unmarshal JSON: %v", err)
+	}
+
+	if val, ok := newReg.GetIndex(0); !ok || val != 69 {
+		t.Errorf("expected 69, got %v", val)
+	}
+	if val, ok := newReg.GetIndex(1); !ok || val != 42 {
+		t.Errorf("expected 42, got %v", val)
+	}
+
+	stdReg := goreg.NewStandardRegistry[int]()
+	if err := stdReg.UnmarshalJSON(data); err != nil {
+		t.Errorf("failed to unmarshal JSON: %v", err)
+	}
+	if !goreg.Equal[int](reg, stdReg) {
+		t.Error("expected equal, but not equal")
+	}
+}
+
 func TestOrderedRegistry_GobCodec(t *testing.T) {
 	reg := goreg.NewOrderedRegistry[int]()
 	reg.Register("kajsmentke", 42)
@@ -0,0 +1,300 @@
+package goreg
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"iter"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrCorrupt is returned when a registry file is corrupt or incomplete.
+var ErrCorrupt = errors.New("goreg: corrupt registry file")
+
+// PersistOptions configures a [PersistentRegistry].
+type PersistOptions[T any] struct {
+	// Codec is the format of the registry file. Defaults to [JSONCodec].
+	Codec Codec[T]
+
+	// AutosaveDelay is how long to wait after the last mutation before saving automatically.
+	// Zero disables autosave.
+	AutosaveDelay time.Duration
+
+	// Perm is the permission used for the registry file. Defaults to 0644.
+	Perm fs.FileMode
+
+	// OnError is called when an autosave fails. Defaults to logging the error with [log/slog].
+	OnError func(err error)
+}
+
+// PersistentRegistry is a registry that is persisted to a file. It wraps another registry.
+//
+// The file is written atomically by writing a temporary file and renaming it over the original.
+type PersistentRegistry[T any] struct {
+	reg  Registry[T]
+	path string
+	opts PersistOptions[T]
+
+	saveMu sync.Mutex // serializes saves
+
+	mu       sync.Mutex
+	timer    *time.Timer
+	dirty    bool
+	closed   bool
+	closeErr error // error of an autosave that was still running when Close was called
+}
+
+// OpenPersistentRegistry creates a new [PersistentRegistry] backed by the file at path.
+// If the file exists, its contents replace the contents of reg. If it does not exist, reg is left untouched.
+//
+// A nil opts is equivalent to a zero [PersistOptions].
+func OpenPersistentRegistry[T any](reg Registry[T], path string, opts *PersistOptions[T]) (*PersistentRegistry[T], error) {
+	r := &PersistentRegistry[T]{reg: reg, path: path}
+	if opts != nil {
+		r.opts = *opts
+	}
+	if r.opts.Codec == nil {
+		r.opts.Codec = JSONCodec[T]{}
+	}
+	if r.opts.Perm == 0 {
+		r.opts.Perm = 0o644
+	}
+	if r.opts.OnError == nil {
+		r.opts.OnError = func(err error) {
+			slog.Error("*goreg.PersistentRegistry: autosave failed", "path", path, "err", err)
+		}
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *PersistentRegistry[T]) load() error {
+	entries, ok, err := readEntriesFile(r.path, r.opts.Codec)
+	if err != nil || !ok {
+		return err
+	}
+
+	r.reg.Reset()
+	for _, e := range entries {
+		r.reg.Register(e.Key, e.Value)
+	}
+
+	return nil
+}
+
+// readEntriesFile decodes the file at path with codec. ok is false if the file does not exist.
+func readEntriesFile[T any](path string, codec Codec[T]) (entries []Entry[T], ok bool, err error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("goreg: loading %s: %w", path, err)
+	}
+
+	if len(data) == 0 {
+		return nil, false, fmt.Errorf("goreg: loading %s: %w: file is empty", path, ErrCorrupt)
+	}
+
+	entries, err = codec.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, false, fmt.Errorf("goreg: loading %s: %w: %w", path, ErrCorrupt, err)
+	}
+
+	return entries, true, nil
+}
+
+// Path returns the path of the registry file.
+func (r *PersistentRegistry[T]) Path() string {
+	return r.path
+}
+
+// Register registers an object under the ID.
+func (r *PersistentRegistry[T]) Register(id string, obj T) {
+	r.reg.Register(id, obj)
+	r.changed()
+}
+
+// Unregister unregisters an object under the ID.
+func (r *PersistentRegistry[T]) Unregister(id string) {
+	r.reg.Unregister(id)
+	r.changed()
+}
+
+// Get returns the object under the ID.
+func (r *PersistentRegistry[T]) Get(id string) (obj T, ok bool) {
+	return r.reg.Get(id)
+}
+
+// MustGet returns the object under the ID and logs error if not found.
+func (r *PersistentRegistry[T]) MustGet(id string) T {
+	obj, ok := r.Get(id)
+	if !ok {
+		slog.Error("*goreg.PersistentRegistry: object not found", "id", id)
+	}
+	return obj
+}
+
+// Len returns the number of items in the registry.
+func (r *PersistentRegistry[T]) Len() int {
+	return r.reg.Len()
+}
+
+// Reset wipes the registry.
+func (r *PersistentRegistry[T]) Reset() {
+	r.reg.Reset()
+	r.changed()
+}
+
+// Iter returns an iterator over key-value pairs. See the [iter] package documentation for more details.
+func (r *PersistentRegistry[T]) Iter() iter.Seq2[string, T] {
+	return r.reg.Iter()
+}
+
+// String returns a string representation of the registry.
+func (r *PersistentRegistry[T]) String() string {
+	return r.reg.String()
+}
+
+func (r *PersistentRegistry[T]) changed() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.dirty = true
+	if r.opts.AutosaveDelay <= 0 || r.closed {
+		return
+	}
+
+	if r.timer == nil {
+		r.timer = time.AfterFunc(r.opts.AutosaveDelay, r.autosave)
+	} else {
+		r.timer.Reset(r.opts.AutosaveDelay)
+	}
+}
+
+func (r *PersistentRegistry[T]) autosave() {
+	r.saveMu.Lock()
+	r.mu.Lock()
+	closed := r.closed
+	r.mu.Unlock()
+	if closed {
+		// Close saves whatever is left.
+		r.saveMu.Unlock()
+		return
+	}
+
+	err := r.save()
+	if err != nil {
+		r.mu.Lock()
+		closed = r.closed
+		if closed && r.closeErr == nil {
+			r.closeErr = err
+		}
+		r.mu.Unlock()
+	}
+	r.saveMu.Unlock()
+
+	// If Close was called in the meantime, it returns the error instead.
+	if err != nil && !closed {
+		r.opts.OnError(err)
+	}
+}
+
+// Save writes the registry to its file.
+func (r *PersistentRegistry[T]) Save() error {
+	r.saveMu.Lock()
+	defer r.saveMu.Unlock()
+	return r.save()
+}
+
+func (r *PersistentRegistry[T]) save() error {
+	r.mu.Lock()
+	r.dirty = false
+	r.mu.Unlock()
+
+	entries := Entries(r.reg)
+	err := writeFileAtomic(r.path, r.opts.Perm, func(w io.Writer) error {
+		return r.opts.Codec.Encode(w, entries)
+	})
+	if err != nil {
+		r.mu.Lock()
+		r.dirty = true
+		r.mu.Unlock()
+		return fmt.Errorf("goreg: saving %s: %w", r.path, err)
+	}
+
+	return nil
+}
+
+// Close stops autosaving and saves the registry if it has unsaved changes.
+// It waits for a running autosave to finish and returns its error, if any.
+func (r *PersistentRegistry[T]) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	r.mu.Unlock()
+
+	r.saveMu.Lock()
+	defer r.saveMu.Unlock()
+
+	r.mu.Lock()
+	err, dirty := r.closeErr, r.dirty
+	r.mu.Unlock()
+
+	if dirty {
+		if saveErr := r.save(); err == nil {
+			err = saveErr
+		}
+	}
+	return err
+}
+
+// writeFileAtomic writes a temporary file in the same directory as path and renames it over path.
+func writeFileAtomic(path string, perm fs.FileMode, write func(w io.Writer) error) (err error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	bw := bufio.NewWriter(f)
+	if err = write(bw); err != nil {
+		return err
+	}
+	if err = bw.Flush(); err != nil {
+		return err
+	}
+	if err = f.Chmod(perm); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
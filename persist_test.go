@@ -0,0 +1,195 @@
+package goreg_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/goreg"
+)
+
+func TestPersistentRegistry_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reg.json")
+
+	reg, err := goreg.OpenPersistentRegistry[int](goreg.NewStandardRegistry[int](), path, nil)
+	if err != nil {
+		t.Fatalf("failed to open registry: %v", err)
+	}
+	reg.Register("kajsmentke", 42)
+	reg.Register("kozmeker", 69)
+	if err := reg.Save(); err != nil {
+		t.Fatalf("failed to save registry: %v", err)
+	}
+
+	newReg, err := goreg.OpenPersistentRegistry[int](goreg.NewStandardRegistry[int](), path, nil)
+	if err != nil {
+		t.Fatalf("failed to open registry: %v", err)
+	}
+
+	if val, ok := newReg.Get("kajsmentke"); !ok || val != 42 {
+		t.Errorf("expected 42, got %v", val)
+	}
+	if val, ok := newReg.Get("kozmeker"); !ok || val != 69 {
+		t.Errorf("expected 69, got %v", val)
+	}
+}
+
+func TestPersistentRegistry_Missing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reg.json")
+
+	reg, err := goreg.OpenPersistentRegistry[int](goreg.NewStandardRegistry[int](), path, nil)
+	if err != nil {
+		t.Fatalf("failed to open registry: %v", err)
+	}
+	if reg.Len() != 0 {
+		t.Errorf("expected length 0, got %d", reg.Len())
+	}
+}
+
+func TestPersistentRegistry_Corrupt(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"Empty", ""},
+		{"Partial", `[{"key":"kajsmentke","value":42},{"key":"koz`},
+		{"Garbage", `hello`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "reg.json")
+			if err := os.WriteFile(path, []byte(test.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := goreg.OpenPersistentRegistry[int](goreg.NewStandardRegistry[int](), path, nil)
+			if !errors.Is(err, goreg.ErrCorrupt) {
+				t.Errorf("expected ErrCorrupt, got %v", err)
+			}
+		})
+	}
+}
+
+func TestPersistentRegistry_Autosave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reg.json")
+
+	reg, err := goreg.OpenPersistentRegistry[int](goreg.NewStandardRegistry[int](), path, &goreg.PersistOptions[int]{AutosaveDelay: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("failed to open registry: %v", err)
+	}
+	reg.Register("kajsmentke", 42)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected autosave to write the file")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := reg.Close(); err != nil {
+		t.Errorf("failed to close registry: %v", err)
+	}
+}
+
+func TestPersistentRegistry_Close(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reg.json")
+
+	reg, err := goreg.OpenPersistentRegistry[int](goreg.NewStandardRegistry[int](), path, nil)
+	if err != nil {
+		t.Fatalf("failed to open registry: %v", err)
+	}
+	reg.Register("kajsmentke", 42)
+	if err := reg.Close(); err != nil {
+		t.Fatalf("failed to close registry: %v", err)
+	}
+
+	newReg, err := goreg.OpenPersistentRegistry[int](goreg.NewStandardRegistry[int](), path, nil)
+	if err != nil {
+		t.Fatalf("failed to open registry: %v", err)
+	}
+	if val, ok := newReg.Get("kajsmentke"); !ok || val != 42 {
+		t.Errorf("expected 42, got %v", val)
+	}
+}
+
+// slowCodec blocks its first Encode until release is closed and then fails it.
+type slowCodec struct {
+	goreg.JSONCodec[int]
+	started, release chan struct{}
+	first            atomic.Bool
+}
+
+func (c *slowCodec) Encode(w io.Writer, entries []goreg.Entry[int]) error {
+	if c.first.CompareAndSwap(false, true) {
+		close(c.started)
+		<-c.release
+		return errors.New("disk full")
+	}
+	return c.JSONCodec.Encode(w, entries)
+}
+
+func TestPersistentRegistry_CloseDuringAutosave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reg.json")
+	codec := &slowCodec{started: make(chan struct{}), release: make(chan struct{})}
+	opts := &goreg.PersistOptions[int]{Codec: codec, AutosaveDelay: time.Millisecond, OnError: func(err error) { t.Errorf("unexpected OnError: %v", err) }}
+
+	reg, err := goreg.OpenPersistentRegistry[int](goreg.NewStandardRegistry[int](), path, opts)
+	if err != nil {
+		t.Fatalf("failed to open registry: %v", err)
+	}
+	reg.Register("kajsmentke", 42)
+	<-codec.started
+
+	closed := make(chan error)
+	go func() { closed <- reg.Close() }()
+	select {
+	case err := <-closed:
+		t.Fatalf("expected Close to wait for the autosave, got %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(codec.release)
+	if err := <-closed; err == nil {
+		t.Error("expected the error of the autosave")
+	}
+
+	// Close retried the save.
+	newReg, err := goreg.OpenPersistentRegistry[int](goreg.NewStandardRegistry[int](), path, nil)
+	if err != nil {
+		t.Fatalf("failed to open registry: %v", err)
+	}
+	if val, ok := newReg.Get("kajsmentke"); !ok || val != 42 {
+		t.Errorf("expected 42, got %v", val)
+	}
+}
+
+func TestPersistentRegistry_Codec(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reg.gob")
+	opts := &goreg.PersistOptions[int]{Codec: goreg.GobCodec[int]{}}
+
+	reg, err := goreg.OpenPersistentRegistry[int](goreg.NewStandardRegistry[int](), path, opts)
+	if err != nil {
+		t.Fatalf("failed to open registry: %v", err)
+	}
+	reg.Register("kajsmentke", 42)
+	if err := reg.Close(); err != nil {
+		t.Fatalf("failed to close registry: %v", err)
+	}
+
+	newReg, err := goreg.OpenPersistentRegistry[int](goreg.NewStandardRegistry[int](), path, opts)
+	if err != nil {
+		t.Fatalf("failed to open registry: %v", err)
+	}
+	if val, ok := newReg.Get("kajsmentke"); !ok || val != 42 {
+		t.Errorf("expected 42, got %v", val)
+	}
+}
@@ -0,0 +1,199 @@
+package goreg
+
+import (
+	"fmt"
+	"iter"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// RawIDMap maps IDs to raw IDs.
+type RawIDMap map[string]uint32
+
+// RawIDRegistry is a registry that assigns a stable numeric raw ID to every ID. It wraps another registry.
+//
+// Raw IDs are assigned in registration order, starting at 0, and never change or get reused:
+// unregistering an object or resetting the registry keeps the raw IDs, so registering the ID again brings back the same number.
+// To keep the numbering across restarts, save the map returned by [RawIDRegistry.RawIDs] and pass it to
+// [RawIDRegistry.SetRawIDs] before registering.
+type RawIDRegistry[T any] struct {
+	reg Registry[T]
+
+	mu    sync.RWMutex
+	ids   RawIDMap
+	names map[uint32]string
+	next  uint64
+}
+
+// NewRawIDRegistry creates a new [RawIDRegistry] wrapping reg.
+// Objects already in reg get raw IDs in sorted order of their IDs.
+func NewRawIDRegistry[T any](reg Registry[T]) *RawIDRegistry[T] {
+	r := &RawIDRegistry[T]{reg: reg, ids: make(RawIDMap), names: make(map[uint32]string)}
+	for _, id := range slices.Sorted(maps.Keys(Collect(reg))) {
+		r.assign(id)
+	}
+	return r
+}
+
+// assign assigns the next raw ID to id if it doesn't have one yet. It reports false if the raw IDs ran out.
+func (r *RawIDRegistry[T]) assign(id string) bool {
+	if _, ok := r.ids[id]; ok {
+		return true
+	}
+	if r.next > maxRawID {
+		return false
+	}
+
+	raw := uint32(r.next)
+	r.ids[id] = raw
+	r.names[raw] = id
+	r.next++
+	return true
+}
+
+const maxRawID = 1<<32 - 1
+
+// Register registers an object under the ID, assigning it a raw ID if it doesn't have one yet.
+func (r *RawIDRegistry[T]) Register(id string, obj T) {
+	r.mu.Lock()
+	ok := r.assign(id)
+	r.mu.Unlock()
+
+	if !ok {
+		slog.Error("*goreg.RawIDRegistry: out of raw IDs", "id", id)
+		return
+	}
+	r.reg.Register(id, obj)
+}
+
+// Unregister unregisters an object under the ID. The raw ID stays assigned to the ID.
+func (r *RawIDRegistry[T]) Unregister(id string) {
+	r.reg.Unregister(id)
+}
+
+// Get returns the object under the ID.
+func (r *RawIDRegistry[T]) Get(id string) (obj T, ok bool) {
+	return r.reg.Get(id)
+}
+
+// MustGet returns the object under the ID and logs error if not found.
+func (r *RawIDRegistry[T]) MustGet(id string) T {
+	obj, ok := r.Get(id)
+	if !ok {
+		slog.Error("*goreg.RawIDRegistry: object not found", "id", id)
+	}
+	return obj
+}
+
+// GetByRawID returns the object under the raw ID.
+func (r *RawIDRegistry[T]) GetByRawID(raw uint32) (obj T, ok bool) {
+	id, ok := r.IDOf(raw)
+	if !ok {
+		return obj, false
+	}
+	return r.reg.Get(id)
+}
+
+// MustGetByRawID returns the object under the raw ID and logs error if not found.
+func (r *RawIDRegistry[T]) MustGetByRawID(raw uint32) T {
+	obj, ok := r.GetByRawID(raw)
+	if !ok {
+		slog.Error("*goreg.RawIDRegistry: object not found", "rawID", raw)
+	}
+	return obj
+}
+
+// RawIDOf returns the raw ID assigned to the ID.
+func (r *RawIDRegistry[T]) RawIDOf(id string) (raw uint32, ok bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	raw, ok = r.ids[id]
+	return
+}
+
+// IDOf returns the ID the raw ID is assigned to.
+func (r *RawIDRegistry[T]) IDOf(raw uint32) (id string, ok bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	id, ok = r.names[raw]
+	return
+}
+
+// RawIDs returns a copy of the raw ID assignments, including the ones of unregistered IDs.
+func (r *RawIDRegistry[T]) RawIDs() RawIDMap {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return maps.Clone(r.ids)
+}
+
+// SetRawIDs replaces the raw ID assignments with m. IDs that are registered but missing from m
+// get new raw IDs after the highest one in m, in sorted order.
+// It returns an error, leaving the assignments untouched, if m assigns the same raw ID to more than one ID.
+func (r *RawIDRegistry[T]) SetRawIDs(m RawIDMap) error {
+	names := make(map[uint32]string, len(m))
+	var next uint64
+	for id, raw := range m {
+		if other, ok := names[raw]; ok {
+			a, b := min(id, other), max(id, other)
+			return fmt.Errorf("goreg: raw ID %d is assigned to both %q and %q", raw, a, b)
+		}
+		names[raw] = id
+		next = max(next, uint64(raw)+1)
+	}
+
+	registered := slices.Sorted(maps.Keys(Collect(r.reg)))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ids = maps.Clone(m)
+	if r.ids == nil {
+		r.ids = make(RawIDMap)
+	}
+	r.names = names
+	r.next = next
+
+	for _, id := range registered {
+		if !r.assign(id) {
+			slog.Error("*goreg.RawIDRegistry: out of raw IDs", "id", id)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of items in the registry.
+func (r *RawIDRegistry[T]) Len() int {
+	return r.reg.Len()
+}
+
+// Reset wipes the registry. The raw IDs stay assigned.
+func (r *RawIDRegistry[T]) Reset() {
+	r.reg.Reset()
+}
+
+// Iter returns an iterator over key-value pairs. See the [iter] package documentation for more details.
+func (r *RawIDRegistry[T]) Iter() iter.Seq2[string, T] {
+	return r.reg.Iter()
+}
+
+// IterRaw returns an iterator over raw ID-value pairs in iteration order.
+func (r *RawIDRegistry[T]) IterRaw() iter.Seq2[uint32, T] {
+	return func(yield func(uint32, T) bool) {
+		for id, obj := range r.reg.Iter() {
+			raw, ok := r.RawIDOf(id)
+			if !ok {
+				continue
+			}
+			if !yield(raw, obj) {
+				return
+			}
+		}
+	}
+}
+
+// String returns a string representation of the registry.
+func (r *RawIDRegistry[T]) String() string {
+	return r.reg.String()
+}
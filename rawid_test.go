@@ -0,0 +1,125 @@
+package goreg_test
+
+import (
+	"encoding/json"
+	"maps"
+	"testing"
+
+	"github.com/MatusOllah/goreg"
+)
+
+func TestRawIDRegistry(t *testing.T) {
+	reg := goreg.NewRawIDRegistry[string](goreg.NewStandardRegistry[string]())
+	reg.Register("stone", "Stone")
+	reg.Register("dirt", "Dirt")
+
+	if raw, ok := reg.RawIDOf("stone"); !ok || raw != 0 {
+		t.Errorf("expected raw ID 0 for stone, got %d", raw)
+	}
+	if raw, ok := reg.RawIDOf("dirt"); !ok || raw != 1 {
+		t.Errorf("expected raw ID 1 for dirt, got %d", raw)
+	}
+	if val, ok := reg.GetByRawID(1); !ok || val != "Dirt" {
+		t.Errorf("expected Dirt, got %v", val)
+	}
+	if _, ok := reg.GetByRawID(2); ok {
+		t.Error("expected raw ID 2 to be not found")
+	}
+
+	// Raw IDs are stable across unregistering and re-registering.
+	reg.Unregister("stone")
+	if _, ok := reg.GetByRawID(0); ok {
+		t.Error("expected raw ID 0 to be not found after unregistering")
+	}
+	reg.Register("grass", "Grass")
+	reg.Register("stone", "Stone")
+	if raw, _ := reg.RawIDOf("stone"); raw != 0 {
+		t.Errorf("expected raw ID 0 for stone, got %d", raw)
+	}
+	if raw, _ := reg.RawIDOf("grass"); raw != 2 {
+		t.Errorf("expected raw ID 2 for grass, got %d", raw)
+	}
+
+	raws := map[uint32]string{}
+	for raw, val := range reg.IterRaw() {
+		raws[raw] = val
+	}
+	expected := map[uint32]string{0: "Stone", 1: "Dirt", 2: "Grass"}
+	if !maps.Equal(raws, expected) {
+		t.Errorf("expected %v, got %v", expected, raws)
+	}
+}
+
+func TestNewRawIDRegistry_Existing(t *testing.T) {
+	inner := goreg.NewStandardRegistry[int]()
+	inner.Register("b", 2)
+	inner.Register("a", 1)
+
+	reg := goreg.NewRawIDRegistry[int](inner)
+	if raw, _ := reg.RawIDOf("a"); raw != 0 {
+		t.Errorf("expected raw ID 0 for a, got %d", raw)
+	}
+	if raw, _ := reg.RawIDOf("b"); raw != 1 {
+		t.Errorf("expected raw ID 1 for b, got %d", raw)
+	}
+}
+
+func TestRawIDRegistry_SetRawIDs(t *testing.T) {
+	reg := goreg.NewRawIDRegistry[string](goreg.NewStandardRegistry[string]())
+	reg.Register("stone", "Stone")
+	reg.Register("dirt", "Dirt")
+
+	// Save and restore the numbering, like across a restart.
+	data, err := json.Marshal(reg.RawIDs())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restarted := goreg.NewRawIDRegistry[string](goreg.NewStandardRegistry[string]())
+	var m goreg.RawIDMap
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	if err := restarted.SetRawIDs(m); err != nil {
+		t.Fatalf("failed to set raw IDs: %v", err)
+	}
+
+	restarted.Register("grass", "Grass")
+	restarted.Register("dirt", "Dirt")
+	restarted.Register("stone", "Stone")
+
+	expected := goreg.RawIDMap{"stone": 0, "dirt": 1, "grass": 2}
+	if got := restarted.RawIDs(); !maps.Equal(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestRawIDRegistry_SetRawIDs_Registered(t *testing.T) {
+	reg := goreg.NewRawIDRegistry[string](goreg.NewStandardRegistry[string]())
+	reg.Register("stone", "Stone")
+	reg.Register("dirt", "Dirt")
+
+	if err := reg.SetRawIDs(goreg.RawIDMap{"dirt": 5}); err != nil {
+		t.Fatalf("failed to set raw IDs: %v", err)
+	}
+
+	expected := goreg.RawIDMap{"dirt": 5, "stone": 6}
+	if got := reg.RawIDs(); !maps.Equal(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	if id, ok := reg.IDOf(6); !ok || id != "stone" {
+		t.Errorf("expected stone, got %q", id)
+	}
+}
+
+func TestRawIDRegistry_SetRawIDs_Duplicate(t *testing.T) {
+	reg := goreg.NewRawIDRegistry[string](goreg.NewStandardRegistry[string]())
+	reg.Register("stone", "Stone")
+
+	if err := reg.SetRawIDs(goreg.RawIDMap{"a": 1, "b": 1}); err == nil {
+		t.Error("expected error for duplicate raw ID")
+	}
+	if raw, ok := reg.RawIDOf("stone"); !ok || raw != 0 {
+		t.Errorf("expected raw IDs to be untouched, got %d", raw)
+	}
+}
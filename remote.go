@@ -0,0 +1,403 @@
+package goreg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RemoteError is returned by [RemoteRegistry] when the server responds with an error.
+type RemoteError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+
+	// Message is the error message sent by the server.
+	Message string
+}
+
+func (e *RemoteError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("goreg: remote: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("goreg: remote: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// RemoteOptions configures a [RemoteRegistry].
+type RemoteOptions struct {
+	// Client is the HTTP client used for requests. Defaults to [http.DefaultClient].
+	Client *http.Client
+
+	// Header is added to every request, for example to carry credentials.
+	Header http.Header
+
+	// Retries is the number of times a request is retried after a network error or a 429 or 5xx response.
+	// Defaults to 2. Set it to a negative number to disable retries.
+	Retries int
+
+	// Backoff is the delay before the first retry. It doubles with every retry. Defaults to 100 ms.
+	Backoff time.Duration
+
+	// Cache enables a local read cache. Cached values are revalidated against the ETag of the registry on every Get,
+	// so unchanged values aren't transferred again. They are only as fresh as that ETag: if the registry is changed
+	// without its ETag changing, Get keeps returning the cached value. [NewHandler] only sends ETags for registries
+	// with a Seq method, and responses without one aren't cached.
+	Cache bool
+
+	// OnError is called when a request made by a method without an error result fails.
+	// Defaults to logging the error with [log/slog].
+	OnError func(err error)
+}
+
+type remoteCached[T any] struct {
+	obj  T
+	etag string
+}
+
+// RemoteRegistry is a registry served by [NewHandler] in another process, accessed over HTTP.
+//
+// The methods of the [Registry] interface report failures to OnError and behave as if the registry was empty.
+// The methods ending in Context take a [context.Context] and return errors instead.
+type RemoteRegistry[T any] struct {
+	base string
+	opts RemoteOptions
+
+	mu    sync.Mutex
+	cache map[string]remoteCached[T]
+}
+
+// NewRemoteRegistry creates a new [RemoteRegistry] for the handler at baseURL.
+//
+// A nil opts is equivalent to a zero [RemoteOptions].
+func NewRemoteRegistry[T any](baseURL string, opts *RemoteOptions) *RemoteRegistry[T] {
+	r := &RemoteRegistry[T]{base: strings.TrimSuffix(baseURL, "/"), cache: make(map[string]remoteCached[T])}
+	if opts != nil {
+		r.opts = *opts
+	}
+	if r.opts.Client == nil {
+		r.opts.Client = http.DefaultClient
+	}
+	if r.opts.Retries == 0 {
+		r.opts.Retries = 2
+	}
+	if r.opts.Backoff <= 0 {
+		r.opts.Backoff = 100 * time.Millisecond
+	}
+	if r.opts.OnError == nil {
+		r.opts.OnError = func(err error) {
+			slog.Error("*goreg.RemoteRegistry: request failed", "err", err)
+		}
+	}
+	return r
+}
+
+// Register registers an object under the ID.
+func (r *RemoteRegistry[T]) Register(id string, obj T) {
+	if err := r.RegisterContext(context.Background(), id, obj); err != nil {
+		r.opts.OnError(err)
+	}
+}
+
+// RegisterContext registers an object under the ID.
+func (r *RemoteRegistry[T]) RegisterContext(ctx context.Context, id string, obj T) error {
+	if id == "" {
+		return ErrEmptyID
+	}
+	body, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	resp, err := r.do(ctx, http.MethodPut, r.itemURL(id), body, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Unregister unregisters an object under the ID.
+func (r *RemoteRegistry[T]) Unregister(id string) {
+	if err := r.UnregisterContext(context.Background(), id); err != nil {
+		r.opts.OnError(err)
+	}
+}
+
+// UnregisterContext unregisters an object under the ID. Unregistering a nonexistent ID is not an error.
+func (r *RemoteRegistry[T]) UnregisterContext(ctx context.Context, id string) error {
+	if id == "" {
+		return ErrEmptyID
+	}
+	resp, err := r.do(ctx, http.MethodDelete, r.itemURL(id), nil, nil)
+	var remoteErr *RemoteError
+	if errors.As(err, &remoteErr) && remoteErr.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get returns the object under the ID.
+func (r *RemoteRegistry[T]) Get(id string) (obj T, ok bool) {
+	obj, ok, err := r.GetContext(context.Background(), id)
+	if err != nil {
+		r.opts.OnError(err)
+	}
+	return obj, ok
+}
+
+// GetContext returns the object under the ID.
+func (r *RemoteRegistry[T]) GetContext(ctx context.Context, id string) (obj T, ok bool, err error) {
+	if id == "" {
+		return obj, false, nil
+	}
+
+	var header http.Header
+	cached, isCached := r.cached(id)
+	if isCached {
+		header = http.Header{"If-None-Match": {cached.etag}}
+	}
+
+	resp, err := r.do(ctx, http.MethodGet, r.itemURL(id), nil, header)
+	var remoteErr *RemoteError
+	if errors.As(err, &remoteErr) && remoteErr.StatusCode == http.StatusNotFound {
+		r.uncache(id)
+		return obj, false, nil
+	}
+	if err != nil {
+		return obj, false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && isCached {
+		return cached.obj, true, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&obj); err != nil {
+		return obj, false, fmt.Errorf("goreg: decoding %q: %w", id, err)
+	}
+	if etag := resp.Header.Get("ETag"); r.opts.Cache && etag != "" {
+		r.mu.Lock()
+		r.cache[id] = remoteCached[T]{obj: obj, etag: etag}
+		r.mu.Unlock()
+	}
+	return obj, true, nil
+}
+
+// MustGet returns the object under the ID and logs error if not found.
+func (r *RemoteRegistry[T]) MustGet(id string) T {
+	obj, ok := r.Get(id)
+	if !ok {
+		slog.Error("*goreg.RemoteRegistry: object not found", "id", id)
+	}
+	return obj
+}
+
+// Len returns the number of items in the registry.
+func (r *RemoteRegistry[T]) Len() int {
+	n, err := r.LenContext(context.Background())
+	if err != nil {
+		r.opts.OnError(err)
+	}
+	return n
+}
+
+// LenContext returns the number of items in the registry.
+func (r *RemoteRegistry[T]) LenContext(ctx context.Context) (int, error) {
+	page, err := r.page(ctx, "", 1)
+	if err != nil {
+		return 0, err
+	}
+	return page.Total, nil
+}
+
+// Reset wipes the registry.
+func (r *RemoteRegistry[T]) Reset() {
+	if err := r.ResetContext(context.Background()); err != nil {
+		r.opts.OnError(err)
+	}
+}
+
+// ResetContext wipes the registry.
+func (r *RemoteRegistry[T]) ResetContext(ctx context.Context) error {
+	resp, err := r.do(ctx, http.MethodDelete, r.base+"/", nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	r.mu.Lock()
+	clear(r.cache)
+	r.mu.Unlock()
+	return nil
+}
+
+// Iter returns an iterator over key-value pairs, sorted by ID. See the [iter] package documentation for more details.
+// It fetches the entries a page at a time.
+func (r *RemoteRegistry[T]) Iter() iter.Seq2[string, T] {
+	return func(yield func(string, T) bool) {
+		for e, err := range r.IterContext(context.Background()) {
+			if err != nil {
+				r.opts.OnError(err)
+				return
+			}
+			if !yield(e.Key, e.Value) {
+				return
+			}
+		}
+	}
+}
+
+// IterContext returns an iterator over the entries, sorted by ID. It fetches the entries a page at a time
+// and stops after yielding the first error.
+func (r *RemoteRegistry[T]) IterContext(ctx context.Context) iter.Seq2[Entry[T], error] {
+	return func(yield func(Entry[T], error) bool) {
+		after := ""
+		for {
+			page, err := r.page(ctx, after, 0)
+			if err != nil {
+				yield(Entry[T]{}, err)
+				return
+			}
+			for _, e := range page.Entries {
+				if !yield(e, nil) {
+					return
+				}
+			}
+			if page.Next == "" {
+				return
+			}
+			after = page.Next
+		}
+	}
+}
+
+// String returns a string representation of the registry.
+func (r *RemoteRegistry[T]) String() string {
+	var entries []Entry[T]
+	for id, obj := range r.Iter() {
+		entries = append(entries, Entry[T]{Key: id, Value: obj})
+	}
+	return fmt.Sprintf("%v", entries)
+}
+
+func (r *RemoteRegistry[T]) page(ctx context.Context, after string, limit int) (*Page[T], error) {
+	q := url.Values{}
+	if after != "" {
+		q.Set("after", after)
+	}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	u := r.base + "/"
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+
+	resp, err := r.do(ctx, http.MethodGet, u, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var page Page[T]
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("goreg: decoding page: %w", err)
+	}
+	return &page, nil
+}
+
+func (r *RemoteRegistry[T]) itemURL(id string) string {
+	return r.base + "/" + url.PathEscape(id)
+}
+
+func (r *RemoteRegistry[T]) cached(id string) (remoteCached[T], bool) {
+	if !r.opts.Cache {
+		return remoteCached[T]{}, false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.cache[id]
+	return c, ok
+}
+
+func (r *RemoteRegistry[T]) uncache(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.cache, id)
+}
+
+// do sends a request, retrying it after network errors and retryable responses. Error responses are returned as
+// [*RemoteError]. The caller must close the body of the returned response.
+func (r *RemoteRegistry[T]) do(ctx context.Context, method, u string, body []byte, header http.Header) (*http.Response, error) {
+	backoff := r.opts.Backoff
+	for attempt := 0; ; attempt++ {
+		resp, err := r.send(ctx, method, u, body, header)
+		if err == nil && resp.StatusCode < 400 {
+			return resp, nil
+		}
+
+		retryable := ctx.Err() == nil
+		if err == nil {
+			err = readRemoteError(resp)
+			retryable = retryable && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500)
+		}
+		if !retryable || attempt >= r.opts.Retries {
+			return nil, err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+func (r *RemoteRegistry[T]) send(ctx context.Context, method, u string, body []byte, header http.Header) (*http.Response, error) {
+	var rd io.Reader
+	if body != nil {
+		rd = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, rd)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range r.opts.Header {
+		req.Header[k] = v
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return r.opts.Client.Do(req)
+}
+
+// readRemoteError reads an error response and closes its body.
+func readRemoteError(resp *http.Response) error {
+	defer resp.Body.Close()
+
+	err := &RemoteError{StatusCode: resp.StatusCode}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var body httpError
+	if json.Unmarshal(data, &body) == nil && body.Error != "" {
+		err.Message = body.Error
+	} else {
+		err.Message = strings.TrimSpace(string(data))
+	}
+	return err
+}
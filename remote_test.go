@@ -0,0 +1,158 @@
+package goreg_test
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/goreg"
+)
+
+// newRemote serves reg with h wrapped by wrap and returns a client for it.
+func newRemote(t *testing.T, reg goreg.Registry[int], wrap func(http.Handler) http.Handler, opts *goreg.RemoteOptions) *goreg.RemoteRegistry[int] {
+	t.Helper()
+	var h http.Handler = goreg.NewHandler[int](reg, &goreg.HandlerOptions{PageSize: 2})
+	if wrap != nil {
+		h = wrap(h)
+	}
+	srv := httptest.NewServer(http.StripPrefix("/registry", h))
+	t.Cleanup(srv.Close)
+	return goreg.NewRemoteRegistry[int](srv.URL+"/registry", opts)
+}
+
+func TestRemoteRegistry(t *testing.T) {
+	reg := goreg.NewStandardRegistry[int]()
+	remote := newRemote(t, reg, nil, &goreg.RemoteOptions{OnError: func(err error) { t.Error(err) }})
+
+	remote.Register("kozmeker", 69)
+	remote.Register("kajsmentke", 42)
+	remote.Register("mod/kocurkovo", 1)
+	remote.Register("a", 0)
+	remote.Register("b", 0)
+
+	if val, ok := remote.Get("mod/kocurkovo"); !ok || val != 1 {
+		t.Errorf("expected 1, got %v", val)
+	}
+	if _, ok := remote.Get("nope"); ok {
+		t.Error("expected key nope to be not found")
+	}
+	if remote.Len() != 5 {
+		t.Errorf("expected length 5, got %d", remote.Len())
+	}
+
+	remote.Unregister("a")
+	remote.Unregister("a") // not an error
+	// Equal can't be used here, since StandardRegistry.Iter holds its lock while the server handles Get.
+	if !maps.Equal(goreg.Collect[int](reg), goreg.Collect[int](remote)) {
+		t.Errorf("expected %s, got %s", reg, remote)
+	}
+	if expected := "[{b 0} {kajsmentke 42} {kozmeker 69} {mod/kocurkovo 1}]"; remote.String() != expected {
+		t.Errorf("expected %s, got %s", expected, remote)
+	}
+
+	remote.Reset()
+	if reg.Len() != 0 {
+		t.Errorf("expected length 0, got %d", reg.Len())
+	}
+}
+
+func TestRemoteRegistry_Retries(t *testing.T) {
+	reg := goreg.NewStandardRegistry[int]()
+	reg.Register("kozmeker", 69)
+
+	var failures atomic.Int32
+	failures.Store(2)
+	flaky := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if failures.Add(-1) >= 0 {
+				http.Error(w, "try again", http.StatusServiceUnavailable)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	remote := newRemote(t, reg, flaky, &goreg.RemoteOptions{Backoff: time.Millisecond})
+
+	val, ok, err := remote.GetContext(context.Background(), "kozmeker")
+	if err != nil || !ok || val != 69 {
+		t.Errorf("expected 69 after retrying, got %v, %v, %v", val, ok, err)
+	}
+
+	failures.Store(5)
+	_, _, err = remote.GetContext(context.Background(), "kozmeker")
+	var remoteErr *goreg.RemoteError
+	if !errors.As(err, &remoteErr) || remoteErr.StatusCode != http.StatusServiceUnavailable || remoteErr.Message != "try again" {
+		t.Errorf("expected 503 RemoteError, got %v", err)
+	}
+}
+
+func TestRemoteRegistry_Errors(t *testing.T) {
+	reg := goreg.NewStandardRegistry[int]()
+	var h http.Handler = goreg.NewHandler[int](reg, &goreg.HandlerOptions{ReadOnly: true})
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	var reported error
+	remote := goreg.NewRemoteRegistry[int](srv.URL, &goreg.RemoteOptions{OnError: func(err error) { reported = err }})
+
+	remote.Register("kozmeker", 69)
+	var remoteErr *goreg.RemoteError
+	if !errors.As(reported, &remoteErr) || remoteErr.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 RemoteError, got %v", reported)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := remote.RegisterContext(ctx, "kozmeker", 69); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if err := remote.RegisterContext(context.Background(), "", 69); !errors.Is(err, goreg.ErrEmptyID) {
+		t.Errorf("expected ErrEmptyID, got %v", err)
+	}
+}
+
+func TestRemoteRegistry_Cache(t *testing.T) {
+	reg := goreg.NewFeedRegistry[int](goreg.NewStandardRegistry[int](), nil)
+	reg.Register("kozmeker", 69)
+
+	var notModified atomic.Int32
+	count := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := httptest.NewRecorder()
+			next.ServeHTTP(rec, r)
+			if rec.Code == http.StatusNotModified {
+				notModified.Add(1)
+			}
+			for k, v := range rec.Header() {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(rec.Code)
+			w.Write(rec.Body.Bytes())
+		})
+	}
+	remote := newRemote(t, reg, count, &goreg.RemoteOptions{Cache: true})
+
+	for range 3 {
+		if val, _ := remote.Get("kozmeker"); val != 69 {
+			t.Errorf("expected 69, got %d", val)
+		}
+	}
+	if n := notModified.Load(); n != 2 {
+		t.Errorf("expected 2 cached reads, got %d", n)
+	}
+
+	// A change on the server invalidates the cache.
+	reg.Register("kozmeker", 70)
+	if val, _ := remote.Get("kozmeker"); val != 70 {
+		t.Errorf("expected 70, got %d", val)
+	}
+	reg.Unregister("kozmeker")
+	if _, ok := remote.Get("kozmeker"); ok {
+		t.Error("expected key kozmeker to be not found")
+	}
+}
@@ -0,0 +1,471 @@
+package goreg
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const replicationVersion = 1
+
+// ErrSlowFollower is returned by [Publisher.Serve] when a follower falls so far behind that changes had to be dropped.
+// The follower can reconnect and resume.
+var ErrSlowFollower = errors.New("goreg: follower too slow")
+
+// PublisherOptions configures a [Publisher].
+type PublisherOptions struct {
+	// Backlog is the number of recent changes kept for followers resuming from a sequence number.
+	// Followers that are further behind get a snapshot. Defaults to 1024.
+	Backlog int
+
+	// Buffer is the number of changes buffered for every follower before it is dropped with [ErrSlowFollower].
+	// Defaults to 256.
+	Buffer int
+
+	// SnapshotChunk is the approximate number of bytes of encoded entries sent in one record of a snapshot.
+	// Snapshots larger than that are split across several records. Defaults to 1 MiB.
+	SnapshotChunk int
+}
+
+// Publisher is a registry that publishes its mutations to followers in other processes. It wraps another registry.
+//
+// Every mutation gets a sequence number. A follower connects with [Publisher.Serve] and receives a snapshot
+// followed by every change made after it, or only the changes it missed if it resumes from a sequence number
+// that is still in the backlog. Records are length-prefixed, checksummed frames holding JSON objects.
+//
+// Sequence numbers are only meaningful within one Publisher, so followers of a restarted publisher get a new snapshot.
+type Publisher[T any] struct {
+	reg   Registry[T]
+	opts  PublisherOptions
+	epoch string
+
+	mu      sync.Mutex // serializes mutations so that the sequence order matches the apply order
+	backlog changeLog[T]
+	subs    map[chan Change[T]]struct{}
+	closed  bool
+}
+
+// NewPublisher creates a new [Publisher] wrapping reg.
+//
+// A nil opts is equivalent to a zero [PublisherOptions].
+func NewPublisher[T any](reg Registry[T], opts *PublisherOptions) *Publisher[T] {
+	p := &Publisher[T]{reg: reg, subs: make(map[chan Change[T]]struct{})}
+	if opts != nil {
+		p.opts = *opts
+	}
+	if p.opts.Backlog <= 0 {
+		p.opts.Backlog = 1024
+	}
+	if p.opts.Buffer <= 0 {
+		p.opts.Buffer = 256
+	}
+	if p.opts.SnapshotChunk <= 0 {
+		p.opts.SnapshotChunk = 1 << 20
+	}
+	p.backlog = newChangeLog[T](p.opts.Backlog)
+
+	p.epoch = newEpoch()
+
+	return p
+}
+
+// newEpoch returns a random ID that tells sequence numbers of different processes apart.
+func newEpoch() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// Register registers an object under the ID.
+func (p *Publisher[T]) Register(id string, obj T) {
+	p.mutate(Change[T]{Op: OpRegister, ID: id, Value: obj})
+}
+
+// Unregister unregisters an object under the ID.
+func (p *Publisher[T]) Unregister(id string) {
+	p.mutate(Change[T]{Op: OpUnregister, ID: id})
+}
+
+// Get returns the object under the ID.
+func (p *Publisher[T]) Get(id string) (obj T, ok bool) {
+	return p.reg.Get(id)
+}
+
+// MustGet returns the object under the ID and logs error if not found.
+func (p *Publisher[T]) MustGet(id string) T {
+	obj, ok := p.Get(id)
+	if !ok {
+		slog.Error("*goreg.Publisher: object not found", "id", id)
+	}
+	return obj
+}
+
+// Len returns the number of items in the registry.
+func (p *Publisher[T]) Len() int {
+	return p.reg.Len()
+}
+
+// Reset wipes the registry.
+func (p *Publisher[T]) Reset() {
+	p.mutate(Change[T]{Op: OpReset})
+}
+
+// Iter returns an iterator over key-value pairs. See the [iter] package documentation for more details.
+func (p *Publisher[T]) Iter() iter.Seq2[string, T] {
+	return p.reg.Iter()
+}
+
+// String returns a string representation of the registry.
+func (p *Publisher[T]) String() string {
+	return p.reg.String()
+}
+
+// Seq returns the sequence number of the last mutation.
+func (p *Publisher[T]) Seq() uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.backlog.seq
+}
+
+func (p *Publisher[T]) mutate(c Change[T]) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	c.apply(p.reg)
+	c = p.backlog.add(c)
+
+	for sub := range p.subs {
+		select {
+		case sub <- c:
+		default:
+			// Drop the follower instead of blocking the writer.
+			delete(p.subs, sub)
+			close(sub)
+		}
+	}
+}
+
+// replHello is sent by a follower to start a session.
+type replHello struct {
+	Version int    `json:"version"`
+	Epoch   string `json:"epoch,omitempty"`
+	Seq     uint64 `json:"seq"`
+}
+
+// replRecord is sent by the publisher. The first record is a snapshot or a resume, every following one is a change.
+// A snapshot may be split across several records, all but the last of which have More set.
+type replRecord[T any] struct {
+	Type    string     `json:"type"`
+	Epoch   string     `json:"epoch,omitempty"`
+	Seq     uint64     `json:"seq,omitempty"`
+	Entries []Entry[T] `json:"entries,omitempty"`
+	More    bool       `json:"more,omitempty"`
+	Change  *Change[T] `json:"change,omitempty"`
+	Error   string     `json:"error,omitempty"`
+}
+
+const (
+	replSnapshot = "snapshot"
+	replResume   = "resume"
+	replChange   = "change"
+	replError    = "error"
+)
+
+// Serve streams changes to a follower connected over rw until the publisher is closed or the follower disconnects,
+// which return nil, or writing to rw fails. It blocks, so it is usually run on its own goroutine for every connection.
+// Serve waits for the follower to disconnect by reading from rw. To stop reading when it returns for another reason,
+// it sets a read deadline if rw has a SetReadDeadline method, like [net.Conn], and resets it afterwards. Otherwise it
+// closes rw if it is an [io.Closer]. Since Serve can block writing to a stalled follower, rw should have a write timeout.
+func (p *Publisher[T]) Serve(rw io.ReadWriter) error {
+	var hello replHello
+	if err := readReplMessage(rw, &hello); err != nil {
+		return err
+	}
+	if hello.Version != replicationVersion {
+		writeReplMessage(rw, replRecord[T]{Type: replError, Error: fmt.Sprintf("unsupported replication version %d", hello.Version)})
+		return fmt.Errorf("%w: replication version %d", ErrUnsupportedVersion, hello.Version)
+	}
+
+	sub := make(chan Change[T], p.opts.Buffer)
+	first, pending, ok := p.subscribe(hello, sub)
+	if !ok {
+		return nil
+	}
+	defer p.unsubscribe(sub)
+
+	if first.Type == replSnapshot {
+		if err := p.writeSnapshot(rw, first); err != nil {
+			return err
+		}
+	} else if err := writeReplMessage(rw, first); err != nil {
+		return err
+	}
+	for _, c := range pending {
+		if err := writeReplMessage(rw, replRecord[T]{Type: replChange, Change: &c}); err != nil {
+			return err
+		}
+	}
+
+	// The follower doesn't send anything after the hello, so reading only returns once it disconnects.
+	gone := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, rw)
+		close(gone)
+	}()
+	defer stopReading(rw, gone)
+
+	for {
+		select {
+		case c, ok := <-sub:
+			if !ok {
+				return p.dropped()
+			}
+			if err := writeReplMessage(rw, replRecord[T]{Type: replChange, Change: &c}); err != nil {
+				return p.failed(sub, err)
+			}
+		case <-gone:
+			return nil
+		}
+	}
+}
+
+// stopReading interrupts the read from rw that closes gone when it returns and waits for it.
+// If rw can neither be given a read deadline nor closed, the read only returns once the caller closes rw.
+func stopReading(rw io.ReadWriter, gone <-chan struct{}) {
+	if d, ok := rw.(interface{ SetReadDeadline(time.Time) error }); ok && d.SetReadDeadline(time.Now()) == nil {
+		<-gone
+		d.SetReadDeadline(time.Time{})
+	} else if c, ok := rw.(io.Closer); ok {
+		c.Close()
+		<-gone
+	}
+}
+
+// writeSnapshot writes a snapshot record, split into records of about [PublisherOptions.SnapshotChunk] bytes
+// of encoded entries, so that large registries don't exceed the maximum frame size.
+func (p *Publisher[T]) writeSnapshot(w io.Writer, snap replRecord[T]) error {
+	chunk := replRecord[json.RawMessage]{Type: replSnapshot, Epoch: snap.Epoch, Seq: snap.Seq}
+	size := 0
+	for _, e := range snap.Entries {
+		value, err := json.Marshal(e.Value)
+		if err != nil {
+			return fmt.Errorf("goreg: replication: encoding %q: %w", e.Key, err)
+		}
+		if size > 0 && size+len(e.Key)+len(value) > p.opts.SnapshotChunk {
+			chunk.More = true
+			if err := writeReplMessage(w, chunk); err != nil {
+				return err
+			}
+			chunk.Entries, size = nil, 0
+		}
+		chunk.Entries = append(chunk.Entries, Entry[json.RawMessage]{Key: e.Key, Value: value})
+		size += len(e.Key) + len(value)
+	}
+	chunk.More = false
+	return writeReplMessage(w, chunk)
+}
+
+// dropped returns the reason a follower's channel was closed.
+func (p *Publisher[T]) dropped() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil
+	}
+	return ErrSlowFollower
+}
+
+// failed returns err, wrapped in [ErrSlowFollower] if the follower was dropped while writing.
+func (p *Publisher[T]) failed(sub chan Change[T], err error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.subs[sub]; !ok && !p.closed {
+		return fmt.Errorf("%w: %w", ErrSlowFollower, err)
+	}
+	return err
+}
+
+// subscribe registers sub and returns the first record along with the backlogged changes to send the follower.
+func (p *Publisher[T]) subscribe(hello replHello, sub chan Change[T]) (first replRecord[T], pending []Change[T], ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return first, nil, false
+	}
+	p.subs[sub] = struct{}{}
+
+	// The follower can resume if it is at the current sequence number or the next change it needs is in the backlog.
+	if hello.Epoch == p.epoch {
+		if pending, ok := p.backlog.since(hello.Seq); ok {
+			return replRecord[T]{Type: replResume, Epoch: p.epoch, Seq: hello.Seq}, pending, true
+		}
+	}
+
+	return replRecord[T]{Type: replSnapshot, Epoch: p.epoch, Seq: p.backlog.seq, Entries: Entries(p.reg)}, nil, true
+}
+
+func (p *Publisher[T]) unsubscribe(sub chan Change[T]) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.subs[sub]; ok {
+		delete(p.subs, sub)
+		close(sub)
+	}
+}
+
+// Close disconnects all followers. Mutations are still applied to the wrapped registry, but no longer published.
+func (p *Publisher[T]) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	for sub := range p.subs {
+		delete(p.subs, sub)
+		close(sub)
+	}
+	return nil
+}
+
+// Follower applies the changes published by a [Publisher] to a local registry.
+type Follower[T any] struct {
+	reg Registry[T]
+
+	mu    sync.Mutex
+	epoch string
+	seq   uint64
+}
+
+// NewFollower creates a new [Follower] applying changes to reg.
+func NewFollower[T any](reg Registry[T]) *Follower[T] {
+	return &Follower[T]{reg: reg}
+}
+
+// Seq returns the sequence number of the last applied change.
+func (f *Follower[T]) Seq() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.seq
+}
+
+// Run connects to a publisher over rw and applies the changes it sends to the local registry
+// until the publisher ends the stream, which returns nil, or an error occurs.
+//
+// On the first call, the local registry is replaced with a snapshot. Later calls, such as after a reconnect,
+// resume from the last applied change if the publisher still has it, and fall back to a snapshot otherwise.
+// Run must not be called concurrently.
+func (f *Follower[T]) Run(rw io.ReadWriter) error {
+	f.mu.Lock()
+	hello := replHello{Version: replicationVersion, Epoch: f.epoch, Seq: f.seq}
+	f.mu.Unlock()
+
+	if err := writeReplMessage(rw, hello); err != nil {
+		return err
+	}
+
+	var first replRecord[T]
+	if err := readReplMessage(rw, &first); err != nil {
+		if err == io.EOF {
+			return fmt.Errorf("goreg: replication: %w", io.ErrUnexpectedEOF)
+		}
+		return err
+	}
+
+	switch first.Type {
+	case replSnapshot:
+		entries := first.Entries
+		for rec := first; rec.More; {
+			rec = replRecord[T]{}
+			if err := readReplMessage(rw, &rec); err != nil {
+				if err == io.EOF {
+					return fmt.Errorf("goreg: replication: %w", io.ErrUnexpectedEOF)
+				}
+				return err
+			}
+			if rec.Type != replSnapshot || rec.Epoch != first.Epoch || rec.Seq != first.Seq {
+				return fmt.Errorf("goreg: replication: unexpected %q record in snapshot", rec.Type)
+			}
+			entries = append(entries, rec.Entries...)
+		}
+
+		f.mu.Lock()
+		f.reg.Reset()
+		for _, e := range entries {
+			f.reg.Register(e.Key, e.Value)
+		}
+		f.epoch, f.seq = first.Epoch, first.Seq
+		f.mu.Unlock()
+	case replResume:
+		if first.Epoch != hello.Epoch || first.Seq != hello.Seq {
+			return fmt.Errorf("goreg: replication: publisher resumed from %s/%d, expected %s/%d", first.Epoch, first.Seq, hello.Epoch, hello.Seq)
+		}
+	case replError:
+		return fmt.Errorf("goreg: replication rejected by publisher: %s", first.Error)
+	default:
+		return fmt.Errorf("goreg: replication: unexpected %q record", first.Type)
+	}
+
+	for {
+		var rec replRecord[T]
+		if err := readReplMessage(rw, &rec); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if rec.Type != replChange || rec.Change == nil {
+			return fmt.Errorf("goreg: replication: unexpected %q record", rec.Type)
+		}
+
+		if err := f.apply(*rec.Change); err != nil {
+			return err
+		}
+	}
+}
+
+func (f *Follower[T]) apply(c Change[T]) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if c.Seq != f.seq+1 {
+		return fmt.Errorf("goreg: replication: expected change %d, got %d", f.seq+1, c.Seq)
+	}
+	if err := c.apply(f.reg); err != nil {
+		return fmt.Errorf("goreg: replication: %w", err)
+	}
+	f.seq = c.Seq
+	return nil
+}
+
+func writeReplMessage(w io.Writer, msg any) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("goreg: replication: %w", err)
+	}
+	if _, err := writeFrame(w, payload); err != nil {
+		return fmt.Errorf("goreg: replication: %w", err)
+	}
+	return nil
+}
+
+// readReplMessage reads a message. It returns [io.EOF] if the stream ended cleanly between messages.
+func readReplMessage(r io.Reader, msg any) error {
+	payload, err := readFrame(r)
+	if err != nil {
+		if err == io.EOF {
+			return err
+		}
+		return fmt.Errorf("goreg: replication: %w", err)
+	}
+	if err := json.Unmarshal(payload, msg); err != nil {
+		return fmt.Errorf("goreg: replication: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,315 @@
+package goreg_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/goreg"
+)
+
+// follow connects follower to pub over a pipe. The returned function disconnects the follower and returns
+// the results of both sides.
+func follow(t *testing.T, pub *goreg.Publisher[int], follower *goreg.Follower[int]) func() (followErr, serveErr error) {
+	t.Helper()
+
+	pubConn, followConn := net.Pipe()
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- pub.Serve(pubConn)
+	}()
+	followErr := make(chan error, 1)
+	go func() {
+		followErr <- follower.Run(followConn)
+	}()
+
+	return func() (error, error) {
+		followConn.Close()
+		pubConn.Close()
+		return <-followErr, <-serveErr
+	}
+}
+
+// waitSeq waits until follower has applied the change with sequence number seq.
+func waitSeq(t *testing.T, follower *goreg.Follower[int], seq uint64) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for follower.Seq() < seq {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for change %d, at %d", seq, follower.Seq())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPublisher(t *testing.T) {
+	pub := goreg.NewPublisher[int](goreg.NewStandardRegistry[int](), nil)
+	pub.Register("kozmeker", 69)
+	pub.Register("kajsmentke", 42)
+
+	replica := goreg.NewStandardRegistry[int]()
+	replica.Register("invalid", 0)
+	follower := goreg.NewFollower[int](replica)
+	disconnect := follow(t, pub, follower)
+
+	waitSeq(t, follower, 2)
+	pub.Unregister("kozmeker")
+	pub.Register("kocurkovo", 1)
+	waitSeq(t, follower, 4)
+
+	if !goreg.Equal[int](replica, pub) {
+		t.Errorf("expected %s, got %s", pub, replica)
+	}
+
+	if followErr, serveErr := disconnect(); serveErr != nil {
+		t.Errorf("expected no error from Serve, got %v (follower: %v)", serveErr, followErr)
+	}
+}
+
+func TestFollower_Resume(t *testing.T) {
+	pub := goreg.NewPublisher[int](goreg.NewStandardRegistry[int](), nil)
+	pub.Register("kozmeker", 69)
+
+	replica := goreg.NewStandardRegistry[int]()
+	follower := goreg.NewFollower[int](replica)
+	disconnect := follow(t, pub, follower)
+	waitSeq(t, follower, 1)
+	disconnect()
+
+	// Changes made while disconnected are sent on resume, without a snapshot.
+	pub.Register("kajsmentke", 42)
+	pub.Unregister("kozmeker")
+	replica.Register("local", 1)
+
+	disconnect = follow(t, pub, follower)
+	waitSeq(t, follower, 3)
+	disconnect()
+
+	if _, ok := replica.Get("local"); !ok {
+		t.Error("expected resume to keep the local registry instead of taking a snapshot")
+	}
+	if _, ok := replica.Get("kozmeker"); ok {
+		t.Error("expected key kozmeker to be not found")
+	}
+	if val, ok := replica.Get("kajsmentke"); !ok || val != 42 {
+		t.Errorf("expected 42, got %v", val)
+	}
+}
+
+func TestFollower_ResumeSnapshot(t *testing.T) {
+	pub := goreg.NewPublisher[int](goreg.NewStandardRegistry[int](), &goreg.PublisherOptions{Backlog: 2})
+	pub.Register("kozmeker", 69)
+
+	replica := goreg.NewStandardRegistry[int]()
+	follower := goreg.NewFollower[int](replica)
+	disconnect := follow(t, pub, follower)
+	waitSeq(t, follower, 1)
+	disconnect()
+
+	// Fall out of the backlog.
+	for i := range 5 {
+		pub.Register("kajsmentke", i)
+	}
+	replica.Register("local", 1)
+
+	disconnect = follow(t, pub, follower)
+	waitSeq(t, follower, 6)
+	disconnect()
+
+	if !goreg.Equal[int](replica, pub) {
+		t.Errorf("expected snapshot %s, got %s", pub, replica)
+	}
+}
+
+// countingConn counts the writes to a connection.
+type countingConn struct {
+	net.Conn
+	writes atomic.Int64
+}
+
+func (c *countingConn) Write(p []byte) (int, error) {
+	c.writes.Add(1)
+	return c.Conn.Write(p)
+}
+
+func TestPublisher_SnapshotChunks(t *testing.T) {
+	pub := goreg.NewPublisher[int](goreg.NewStandardRegistry[int](), &goreg.PublisherOptions{SnapshotChunk: 64})
+	for i := range 100 {
+		pub.Register(fmt.Sprintf("kajsmentke%d", i), i)
+	}
+
+	pubConn, followConn := net.Pipe()
+	conn := &countingConn{Conn: pubConn}
+	go pub.Serve(conn)
+	defer pubConn.Close()
+	defer followConn.Close()
+
+	replica := goreg.NewStandardRegistry[int]()
+	follower := goreg.NewFollower[int](replica)
+	go follower.Run(followConn)
+	waitSeq(t, follower, 100)
+
+	if !goreg.Equal[int](replica, pub) {
+		t.Errorf("expected %s, got %s", pub, replica)
+	}
+	if n := conn.writes.Load(); n < 10 {
+		t.Errorf("expected the snapshot to be split into at least 10 records, got %d", n)
+	}
+}
+
+func TestFollower_NewPublisher(t *testing.T) {
+	pub := goreg.NewPublisher[int](goreg.NewStandardRegistry[int](), nil)
+	pub.Register("kozmeker", 69)
+	pub.Register("kajsmentke", 42)
+
+	replica := goreg.NewStandardRegistry[int]()
+	follower := goreg.NewFollower[int](replica)
+	disconnect := follow(t, pub, follower)
+	waitSeq(t, follower, 2)
+	disconnect()
+
+	// A restarted publisher starts over at sequence number 0, so the follower must take a new snapshot.
+	restarted := goreg.NewPublisher[int](goreg.NewStandardRegistry[int](), nil)
+	restarted.Register("kocurkovo", 1)
+	disconnect = follow(t, restarted, follower)
+	deadline := time.Now().Add(5 * time.Second)
+	for replica.Len() != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	disconnect()
+
+	if !goreg.Equal[int](replica, restarted) {
+		t.Errorf("expected %s, got %s", restarted, replica)
+	}
+}
+
+func TestPublisher_SlowFollower(t *testing.T) {
+	pub := goreg.NewPublisher[int](goreg.NewStandardRegistry[int](), &goreg.PublisherOptions{Buffer: 1})
+
+	pubConn, followConn := net.Pipe()
+	defer pubConn.Close()
+	defer followConn.Close()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- pub.Serve(pubConn)
+	}()
+
+	// Receive the snapshot and the first change, then stop reading.
+	stall := make(chan struct{})
+	defer close(stall)
+	follower := goreg.NewFollower[int](goreg.NewStandardRegistry[int]())
+	go follower.Run(&stallingConn{Conn: followConn, reads: 4, stall: stall})
+	pub.Register("kajsmentke", 0)
+	waitSeq(t, follower, 1)
+
+	// Mutations must not block on the stalled follower.
+	done := make(chan struct{})
+	go func() {
+		for i := range 100 {
+			pub.Register("kajsmentke", i)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("mutations blocked on a slow follower")
+	}
+
+	pubConn.SetWriteDeadline(time.Now())
+	if err := <-serveErr; !errors.Is(err, goreg.ErrSlowFollower) {
+		t.Errorf("expected ErrSlowFollower, got %v", err)
+	}
+}
+
+// stallingConn blocks after a number of reads until stall is closed.
+type stallingConn struct {
+	net.Conn
+	reads int
+	stall chan struct{}
+}
+
+func (c *stallingConn) Read(p []byte) (int, error) {
+	if c.reads == 0 {
+		<-c.stall
+		return 0, io.EOF
+	}
+	c.reads--
+	return c.Conn.Read(p)
+}
+
+func TestPublisher_Close(t *testing.T) {
+	pub := goreg.NewPublisher[int](goreg.NewStandardRegistry[int](), nil)
+	follower := goreg.NewFollower[int](goreg.NewStandardRegistry[int]())
+
+	pubConn, followConn := net.Pipe()
+	defer followConn.Close()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- pub.Serve(pubConn)
+		pubConn.Close()
+	}()
+	followErr := make(chan error, 1)
+	go func() {
+		followErr <- follower.Run(followConn)
+	}()
+
+	pub.Register("kozmeker", 69)
+	waitSeq(t, follower, 1)
+	pub.Close()
+
+	if err := <-serveErr; err != nil {
+		t.Errorf("expected no error from Serve, got %v", err)
+	}
+	if err := <-followErr; err != nil {
+		t.Errorf("expected no error from Run, got %v", err)
+	}
+
+	// Mutations still apply after closing.
+	pub.Register("kajsmentke", 42)
+	if pub.Len() != 2 {
+		t.Errorf("expected length 2, got %d", pub.Len())
+	}
+}
+
+func TestPublisher_CloseStopsReading(t *testing.T) {
+	pub := goreg.NewPublisher[int](goreg.NewStandardRegistry[int](), nil)
+	follower := goreg.NewFollower[int](goreg.NewStandardRegistry[int]())
+
+	pubConn, followConn := net.Pipe()
+	defer pubConn.Close()
+	defer followConn.Close()
+
+	// Without a read deadline, Serve has to close the connection to stop reading from it.
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- pub.Serve(struct{ io.ReadWriteCloser }{pubConn})
+	}()
+	followErr := make(chan error, 1)
+	go func() {
+		followErr <- follower.Run(followConn)
+	}()
+
+	pub.Register("kozmeker", 69)
+	waitSeq(t, follower, 1)
+	pub.Close()
+
+	select {
+	case err := <-serveErr:
+		if err != nil {
+			t.Errorf("expected no error from Serve, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve didn't return after closing the publisher")
+	}
+	if _, err := pubConn.Read(make([]byte, 1)); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("expected the connection to be closed, got %v", err)
+	}
+	<-followErr
+}
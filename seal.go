@@ -0,0 +1,256 @@
+package goreg
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrBadSignature is returned when a sealed blob has an invalid signature, or no signature when one is required.
+var ErrBadSignature = errors.New("goreg: bad signature")
+
+// Keyring provides the keys used by [Seal] and [Unseal]. Every method may return a nil key to skip the step it is used for.
+type Keyring interface {
+	// SigningKey returns the key used to sign blobs.
+	SigningKey() (ed25519.PrivateKey, error)
+
+	// VerifyingKey returns the key used to verify the signatures of blobs.
+	VerifyingKey() (ed25519.PublicKey, error)
+
+	// EncryptionKey returns the AES key used to encrypt and decrypt blobs. It must be 16, 24 or 32 bytes long.
+	EncryptionKey() ([]byte, error)
+}
+
+// StaticKeys is a [Keyring] holding fixed keys.
+type StaticKeys struct {
+	Signing    ed25519.PrivateKey
+	Verifying  ed25519.PublicKey
+	Encryption []byte
+}
+
+// SigningKey returns k.Signing.
+func (k StaticKeys) SigningKey() (ed25519.PrivateKey, error) {
+	return k.Signing, nil
+}
+
+// VerifyingKey returns k.Verifying, or the public half of k.Signing if k.Verifying is nil.
+func (k StaticKeys) VerifyingKey() (ed25519.PublicKey, error) {
+	if k.Verifying == nil && k.Signing != nil {
+		if len(k.Signing) != ed25519.PrivateKeySize {
+			return nil, errors.New("goreg: invalid signing key")
+		}
+		return k.Signing.Public().(ed25519.PublicKey), nil
+	}
+	return k.Verifying, nil
+}
+
+// EncryptionKey returns k.Encryption.
+func (k StaticKeys) EncryptionKey() ([]byte, error) {
+	return k.Encryption, nil
+}
+
+const (
+	sealMagic      = "GRSL"
+	sealVersion    = 1
+	sealHeaderSize = 6
+
+	sealEncrypted = 1 << 0
+	sealSigned    = 1 << 1
+	sealFlags     = sealEncrypted | sealSigned
+)
+
+// Seal wraps data in a sealed blob, encrypting it with AES-GCM if keys has an encryption key
+// and signing it with ed25519 if keys has a signing key.
+//
+// The blob consists of a header (magic "GRSL", version and flags), the data or the nonce followed by the ciphertext,
+// and the signature, which covers everything before it. The header is authenticated as additional data when encrypting.
+func Seal(data []byte, keys Keyring) ([]byte, error) {
+	encKey, err := keys.EncryptionKey()
+	if err != nil {
+		return nil, fmt.Errorf("goreg: getting encryption key: %w", err)
+	}
+	signKey, err := keys.SigningKey()
+	if err != nil {
+		return nil, fmt.Errorf("goreg: getting signing key: %w", err)
+	}
+
+	blob := []byte(sealMagic)
+	blob = append(blob, sealVersion, 0)
+	if encKey != nil {
+		blob[5] |= sealEncrypted
+	}
+	if signKey != nil {
+		blob[5] |= sealSigned
+	}
+
+	if encKey != nil {
+		gcm, err := newGCM(encKey)
+		if err != nil {
+			return nil, err
+		}
+		nonce := make([]byte, gcm.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, err
+		}
+		blob = append(blob, nonce...)
+		blob = gcm.Seal(blob, nonce, data, blob[:sealHeaderSize])
+	} else {
+		blob = append(blob, data...)
+	}
+
+	if signKey != nil {
+		if len(signKey) != ed25519.PrivateKeySize {
+			return nil, errors.New("goreg: invalid signing key")
+		}
+		blob = append(blob, ed25519.Sign(signKey, blob)...)
+	}
+
+	return blob, nil
+}
+
+// Unseal verifies and decrypts a blob created by [Seal] and returns the data.
+//
+// If keys has a verifying key, the blob must be signed and the signature is checked; an unsigned blob or a mismatch
+// is reported as [ErrBadSignature]. Otherwise, if requireSignature is true, every blob is rejected with [ErrBadSignature],
+// since it can't be verified. Blobs with unknown flags are rejected with [ErrUnsupportedVersion].
+func Unseal(blob []byte, keys Keyring, requireSignature bool) ([]byte, error) {
+	if len(blob) < sealHeaderSize || string(blob[:4]) != sealMagic {
+		return nil, fmt.Errorf("goreg: unsealing: %w: not a sealed blob", ErrCorrupt)
+	}
+	if blob[4] != sealVersion {
+		return nil, fmt.Errorf("%w: sealed blob version %d", ErrUnsupportedVersion, blob[4])
+	}
+	flags := blob[5]
+	if flags&^sealFlags != 0 {
+		return nil, fmt.Errorf("%w: sealed blob flags %#x", ErrUnsupportedVersion, flags)
+	}
+
+	verifyKey, err := keys.VerifyingKey()
+	if err != nil {
+		return nil, fmt.Errorf("goreg: getting verifying key: %w", err)
+	}
+
+	if flags&sealSigned != 0 {
+		if len(blob) < sealHeaderSize+ed25519.SignatureSize {
+			return nil, fmt.Errorf("goreg: unsealing: %w: truncated signature", ErrCorrupt)
+		}
+		signed, sig := blob[:len(blob)-ed25519.SignatureSize], blob[len(blob)-ed25519.SignatureSize:]
+		if verifyKey != nil {
+			if len(verifyKey) != ed25519.PublicKeySize || !ed25519.Verify(verifyKey, signed, sig) {
+				return nil, ErrBadSignature
+			}
+		} else if requireSignature {
+			return nil, fmt.Errorf("%w: no verifying key", ErrBadSignature)
+		}
+		blob = signed
+	} else if verifyKey != nil || requireSignature {
+		// With a verifying key, an unsigned blob may be a signed one with the flag cleared and the signature cut off.
+		return nil, fmt.Errorf("%w: blob is not signed", ErrBadSignature)
+	}
+
+	if flags&sealEncrypted == 0 {
+		return blob[sealHeaderSize:], nil
+	}
+
+	encKey, err := keys.EncryptionKey()
+	if err != nil {
+		return nil, fmt.Errorf("goreg: getting encryption key: %w", err)
+	}
+	if encKey == nil {
+		return nil, errors.New("goreg: blob is encrypted, but there is no encryption key")
+	}
+	gcm, err := newGCM(encKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(blob) < sealHeaderSize+gcm.NonceSize() {
+		return nil, fmt.Errorf("goreg: unsealing: %w: truncated nonce", ErrCorrupt)
+	}
+	nonce := blob[sealHeaderSize : sealHeaderSize+gcm.NonceSize()]
+	data, err := gcm.Open(nil, nonce, blob[sealHeaderSize+gcm.NonceSize():], blob[:sealHeaderSize])
+	if err != nil {
+		return nil, fmt.Errorf("goreg: decrypting: %w", err)
+	}
+	return data, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("goreg: invalid encryption key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// SealedCodec is a [Codec] that seals the output of another codec with [Seal].
+type SealedCodec[T any] struct {
+	// Codec is the codec whose output is sealed.
+	Codec Codec[T]
+
+	// Keys provides the keys.
+	Keys Keyring
+
+	// RequireSignature makes Decode reject blobs without a valid signature even if Keys has no verifying key.
+	// Blobs without a valid signature are always rejected if Keys has one.
+	RequireSignature bool
+}
+
+// Encode writes the sealed entries to w.
+func (c SealedCodec[T]) Encode(w io.Writer, entries []Entry[T]) error {
+	var bf bytes.Buffer
+	if err := c.Codec.Encode(&bf, entries); err != nil {
+		return err
+	}
+
+	blob, err := Seal(bf.Bytes(), c.Keys)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(blob)
+	return err
+}
+
+// Decode reads sealed entries from r.
+func (c SealedCodec[T]) Decode(r io.Reader) ([]Entry[T], error) {
+	data, err := c.unseal(r)
+	if err != nil {
+		return nil, err
+	}
+	return c.Codec.Decode(bytes.NewReader(data))
+}
+
+func (c SealedCodec[T]) streamEntries(r io.Reader, strict bool, fn func(e Entry[T], off int64) error) error {
+	data, err := c.unseal(r)
+	if err != nil {
+		return err
+	}
+
+	// Offsets are positions in the unsealed data.
+	if s, ok := c.Codec.(entryStreamer[T]); ok {
+		return s.streamEntries(bytes.NewReader(data), strict, fn)
+	}
+	entries, err := c.Codec.Decode(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := fn(e, -1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c SealedCodec[T]) unseal(r io.Reader) ([]byte, error) {
+	blob, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return Unseal(blob, c.Keys, c.RequireSignature)
+}
@@ -0,0 +1,185 @@
+package goreg_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"testing"
+
+	"github.com/MatusOllah/goreg"
+)
+
+func testKeys(t *testing.T) (ed25519.PrivateKey, []byte) {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv, bytes.Repeat([]byte{0x42}, 32)
+}
+
+func TestSeal(t *testing.T) {
+	priv, encKey := testKeys(t)
+	data := []byte(`{"kajsmentke":42}`)
+
+	tests := []struct {
+		name string
+		keys goreg.StaticKeys
+	}{
+		{"Plain", goreg.StaticKeys{}},
+		{"Signed", goreg.StaticKeys{Signing: priv}},
+		{"Encrypted", goreg.StaticKeys{Encryption: encKey}},
+		{"SignedEncrypted", goreg.StaticKeys{Signing: priv, Encryption: encKey}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			blob, err := goreg.Seal(data, test.keys)
+			if err != nil {
+				t.Fatalf("failed to seal: %v", err)
+			}
+			if test.keys.Encryption != nil && bytes.Contains(blob, data) {
+				t.Error("expected data to be encrypted")
+			}
+
+			got, err := goreg.Unseal(blob, test.keys, test.keys.Signing != nil)
+			if err != nil {
+				t.Fatalf("failed to unseal: %v", err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Errorf("expected %s, got %s", data, got)
+			}
+		})
+	}
+}
+
+func TestUnseal_BadSignature(t *testing.T) {
+	priv, encKey := testKeys(t)
+	otherPriv, _ := testKeys(t)
+	keys := goreg.StaticKeys{Signing: priv, Encryption: encKey}
+
+	blob, err := goreg.Seal([]byte("kozmeker"), keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := bytes.Clone(blob)
+	tampered[10] ^= 1
+	if _, err := goreg.Unseal(tampered, keys, false); !errors.Is(err, goreg.ErrBadSignature) {
+		t.Errorf("expected ErrBadSignature for tampered blob, got %v", err)
+	}
+
+	wrongKey := goreg.StaticKeys{Verifying: otherPriv.Public().(ed25519.PublicKey), Encryption: encKey}
+	if _, err := goreg.Unseal(blob, wrongKey, false); !errors.Is(err, goreg.ErrBadSignature) {
+		t.Errorf("expected ErrBadSignature for wrong key, got %v", err)
+	}
+
+	// Without a verifying key, the signature can only be skipped if it isn't required.
+	noKey := goreg.StaticKeys{Encryption: encKey}
+	if _, err := goreg.Unseal(blob, noKey, false); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if _, err := goreg.Unseal(blob, noKey, true); !errors.Is(err, goreg.ErrBadSignature) {
+		t.Errorf("expected ErrBadSignature without verifying key, got %v", err)
+	}
+}
+
+func TestUnseal_RequireSignature(t *testing.T) {
+	priv, _ := testKeys(t)
+
+	blob, err := goreg.Seal([]byte("kozmeker"), goreg.StaticKeys{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := goreg.Unseal(blob, goreg.StaticKeys{Signing: priv}, true); !errors.Is(err, goreg.ErrBadSignature) {
+		t.Errorf("expected ErrBadSignature for unsigned blob, got %v", err)
+	}
+
+	// A verifying key requires a signature even if requireSignature is false.
+	if _, err := goreg.Unseal(blob, goreg.StaticKeys{Signing: priv}, false); !errors.Is(err, goreg.ErrBadSignature) {
+		t.Errorf("expected ErrBadSignature for unsigned blob with verifying key, got %v", err)
+	}
+}
+
+func TestUnseal_StrippedSignature(t *testing.T) {
+	priv, _ := testKeys(t)
+	keys := goreg.StaticKeys{Signing: priv}
+
+	blob, err := goreg.Seal([]byte("kozmeker"), keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stripped := bytes.Clone(blob[:len(blob)-ed25519.SignatureSize])
+	stripped[5] &^= 1 << 1
+	if _, err := goreg.Unseal(stripped, keys, false); !errors.Is(err, goreg.ErrBadSignature) {
+		t.Errorf("expected ErrBadSignature for stripped signature, got %v", err)
+	}
+}
+
+func TestUnseal_UnknownFlags(t *testing.T) {
+	blob, err := goreg.Seal([]byte("kozmeker"), goreg.StaticKeys{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blob[5] |= 1 << 7
+	if _, err := goreg.Unseal(blob, goreg.StaticKeys{}, false); !errors.Is(err, goreg.ErrUnsupportedVersion) {
+		t.Errorf("expected ErrUnsupportedVersion for unknown flags, got %v", err)
+	}
+}
+
+func TestUnseal_Invalid(t *testing.T) {
+	_, encKey := testKeys(t)
+
+	blob, err := goreg.Seal([]byte("kozmeker"), goreg.StaticKeys{Encryption: encKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := goreg.Unseal([]byte("kozmeker"), goreg.StaticKeys{}, false); !errors.Is(err, goreg.ErrCorrupt) {
+		t.Errorf("expected ErrCorrupt, got %v", err)
+	}
+	if _, err := goreg.Unseal(blob, goreg.StaticKeys{}, false); err == nil {
+		t.Error("expected error without encryption key")
+	}
+	if _, err := goreg.Unseal(blob, goreg.StaticKeys{Encryption: bytes.Repeat([]byte{1}, 32)}, false); err == nil {
+		t.Error("expected error with wrong encryption key")
+	}
+	if _, err := goreg.Unseal(blob[:10], goreg.StaticKeys{Encryption: encKey}, false); !errors.Is(err, goreg.ErrCorrupt) {
+		t.Errorf("expected ErrCorrupt for truncated blob, got %v", err)
+	}
+}
+
+func TestStaticKeys_InvalidSigningKey(t *testing.T) {
+	keys := goreg.StaticKeys{Signing: ed25519.PrivateKey("kozmeker")}
+	if _, err := keys.VerifyingKey(); err == nil {
+		t.Error("expected error for a short signing key")
+	}
+	if _, err := goreg.Unseal([]byte("GRSL\x01\x00kozmeker"), keys, false); err == nil {
+		t.Error("expected error unsealing with a short signing key")
+	}
+}
+
+func TestSealedCodec(t *testing.T) {
+	priv, encKey := testKeys(t)
+	keys := goreg.StaticKeys{Signing: priv, Encryption: encKey}
+
+	testCodecRoundTrip(t, goreg.SealedCodec[int]{Codec: goreg.JSONCodec[int]{}, Keys: keys, RequireSignature: true})
+	testCodecRoundTrip(t, goreg.SealedCodec[int]{Codec: goreg.GobCodec[int]{}, Keys: keys})
+}
+
+func TestSealedCodec_Strict(t *testing.T) {
+	priv, _ := testKeys(t)
+	codec := goreg.SealedCodec[int]{Codec: goreg.JSONCodec[int]{}, Keys: goreg.StaticKeys{Signing: priv}}
+
+	blob, err := goreg.Seal([]byte(`{"a":1,"a":2}`), codec.Keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = goreg.DecodeWith(goreg.NewStandardRegistry[int](), goreg.Codec[int](codec), bytes.NewReader(blob), &goreg.DecodeOptions[int]{Mode: goreg.LoadStrict})
+	if !errors.Is(err, goreg.ErrDuplicateID) {
+		t.Errorf("expected ErrDuplicateID, got %v", err)
+	}
+}
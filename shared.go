@@ -0,0 +1,252 @@
+package goreg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"iter"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// SharedFileOptions configures a [SharedFileRegistry].
+type SharedFileOptions struct {
+	// Perm is the permission used for the registry file and its lock file. Defaults to 0644.
+	Perm fs.FileMode
+
+	// OnError is called when reading or writing the registry file fails. Defaults to logging the error with [log/slog].
+	OnError func(err error)
+}
+
+type sharedFile[T any] struct {
+	Generation uint64     `json:"generation"`
+	Entries    []Entry[T] `json:"entries"`
+}
+
+// SharedFileRegistry is a registry backed by a file that is shared between processes. It wraps another registry.
+//
+// Writers are coordinated with an advisory lock on a lock file next to the registry file (path + ".lock").
+// Every write reloads the file, applies the mutation and writes the file back with an incremented generation number,
+// so concurrent writers never clobber each other's writes.
+// Readers reload the file when it has been replaced since the last read.
+//
+// Advisory locks are only supported on BSD, Darwin and Linux.
+type SharedFileRegistry[T any] struct {
+	reg  Registry[T]
+	path string
+	opts SharedFileOptions
+
+	mu    sync.Mutex
+	lock  *os.File
+	fi    fs.FileInfo
+	gen   uint64
+	stale bool // a failed write left reg ahead of the file
+}
+
+// OpenSharedFileRegistry creates a new [SharedFileRegistry] backed by the file at path.
+// If the file exists, its contents replace the contents of reg.
+//
+// A nil opts is equivalent to a zero [SharedFileOptions].
+func OpenSharedFileRegistry[T any](reg Registry[T], path string, opts *SharedFileOptions) (*SharedFileRegistry[T], error) {
+	r := &SharedFileRegistry[T]{reg: reg, path: path}
+	if opts != nil {
+		r.opts = *opts
+	}
+	if r.opts.Perm == 0 {
+		r.opts.Perm = 0o644
+	}
+	if r.opts.OnError == nil {
+		r.opts.OnError = func(err error) {
+			slog.Error("*goreg.SharedFileRegistry: shared file error", "path", path, "err", err)
+		}
+	}
+
+	lock, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, r.opts.Perm)
+	if err != nil {
+		return nil, fmt.Errorf("goreg: opening lock file: %w", err)
+	}
+	r.lock = lock
+
+	if err := r.Reload(); err != nil {
+		lock.Close()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Path returns the path of the registry file.
+func (r *SharedFileRegistry[T]) Path() string {
+	return r.path
+}
+
+// Generation returns the generation number of the registry file as of the last read or write.
+func (r *SharedFileRegistry[T]) Generation() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.gen
+}
+
+// Reload reloads the registry file if it has changed since the last read.
+func (r *SharedFileRegistry[T]) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := lockFile(r.lock, false); err != nil {
+		return fmt.Errorf("goreg: locking %s: %w", r.path, err)
+	}
+	defer unlockFile(r.lock)
+
+	return r.refresh()
+}
+
+// refresh reloads the registry file if it has been replaced. The caller must hold the file lock.
+func (r *SharedFileRegistry[T]) refresh() error {
+	fi, err := os.Stat(r.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		if r.fi != nil || r.gen != 0 || r.stale {
+			r.reg.Reset()
+		}
+		r.fi, r.gen, r.stale = nil, 0, false
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("goreg: loading %s: %w", r.path, err)
+	}
+
+	if !r.stale && r.fi != nil && os.SameFile(r.fi, fi) && r.fi.ModTime().Equal(fi.ModTime()) && r.fi.Size() == fi.Size() {
+		return nil
+	}
+
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("goreg: loading %s: %w", r.path, err)
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("goreg: loading %s: %w: file is empty", r.path, ErrCorrupt)
+	}
+
+	var sf sharedFile[T]
+	if err := json.Unmarshal(data, &sf); err != nil {
+		return fmt.Errorf("goreg: loading %s: %w: %w", r.path, ErrCorrupt, err)
+	}
+
+	r.fi = fi
+	if !r.stale && r.gen == sf.Generation && r.gen != 0 {
+		return nil
+	}
+	r.gen, r.stale = sf.Generation, false
+
+	r.reg.Reset()
+	for _, e := range sf.Entries {
+		r.reg.Register(e.Key, e.Value)
+	}
+
+	return nil
+}
+
+func (r *SharedFileRegistry[T]) read() {
+	if err := r.Reload(); err != nil {
+		r.opts.OnError(err)
+	}
+}
+
+func (r *SharedFileRegistry[T]) write(c Change[T]) {
+	if err := r.writeChange(c); err != nil {
+		r.opts.OnError(err)
+	}
+}
+
+func (r *SharedFileRegistry[T]) writeChange(c Change[T]) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := lockFile(r.lock, true); err != nil {
+		return fmt.Errorf("goreg: locking %s: %w", r.path, err)
+	}
+	defer unlockFile(r.lock)
+
+	if err := r.refresh(); err != nil {
+		return err
+	}
+
+	c.apply(r.reg)
+
+	sf := sharedFile[T]{Generation: r.gen + 1, Entries: Entries(r.reg)}
+	err := writeFileAtomic(r.path, r.opts.Perm, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(sf)
+	})
+	if err != nil {
+		// The change is only in memory, so reload the file on the next access.
+		r.stale = true
+		return fmt.Errorf("goreg: saving %s: %w", r.path, err)
+	}
+
+	fi, err := os.Stat(r.path)
+	if err != nil {
+		return fmt.Errorf("goreg: saving %s: %w", r.path, err)
+	}
+	r.fi, r.gen = fi, sf.Generation
+
+	return nil
+}
+
+// Register registers an object under the ID.
+func (r *SharedFileRegistry[T]) Register(id string, obj T) {
+	r.write(Change[T]{Op: OpRegister, ID: id, Value: obj})
+}
+
+// Unregister unregisters an object under the ID.
+func (r *SharedFileRegistry[T]) Unregister(id string) {
+	r.write(Change[T]{Op: OpUnregister, ID: id})
+}
+
+// Get returns the object under the ID.
+func (r *SharedFileRegistry[T]) Get(id string) (obj T, ok bool) {
+	r.read()
+	return r.reg.Get(id)
+}
+
+// MustGet returns the object under the ID and logs error if not found.
+func (r *SharedFileRegistry[T]) MustGet(id string) T {
+	obj, ok := r.Get(id)
+	if !ok {
+		slog.Error("*goreg.SharedFileRegistry: object not found", "id", id)
+	}
+	return obj
+}
+
+// Len returns the number of items in the registry.
+func (r *SharedFileRegistry[T]) Len() int {
+	r.read()
+	return r.reg.Len()
+}
+
+// Reset wipes the registry.
+func (r *SharedFileRegistry[T]) Reset() {
+	r.write(Change[T]{Op: OpReset})
+}
+
+// Iter returns an iterator over key-value pairs. See the [iter] package documentation for more details.
+func (r *SharedFileRegistry[T]) Iter() iter.Seq2[string, T] {
+	return func(yield func(string, T) bool) {
+		r.read()
+		r.reg.Iter()(yield)
+	}
+}
+
+// String returns a string representation of the registry.
+func (r *SharedFileRegistry[T]) String() string {
+	r.read()
+	return r.reg.String()
+}
+
+// Close closes the lock file.
+func (r *SharedFileRegistry[T]) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lock.Close()
+}
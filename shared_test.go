@@ -0,0 +1,104 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package goreg_test
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/MatusOllah/goreg"
+)
+
+func openSharedFileRegistry(t *testing.T, path string) *goreg.SharedFileRegistry[int] {
+	t.Helper()
+
+	reg, err := goreg.OpenSharedFileRegistry[int](goreg.NewStandardRegistry[int](), path, &goreg.SharedFileOptions{
+		OnError: func(err error) { t.Error(err) },
+	})
+	if err != nil {
+		t.Fatalf("failed to open registry: %v", err)
+	}
+	t.Cleanup(func() { reg.Close() })
+
+	return reg
+}
+
+func TestSharedFileRegistry_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reg.json")
+	reg1 := openSharedFileRegistry(t, path)
+	reg2 := openSharedFileRegistry(t, path)
+
+	reg1.Register("kajsmentke", 42)
+	if val, ok := reg2.Get("kajsmentke"); !ok || val != 42 {
+		t.Errorf("expected 42, got %v", val)
+	}
+
+	reg2.Register("kozmeker", 69)
+	if reg1.Len() != 2 {
+		t.Errorf("expected length 2, got %d", reg1.Len())
+	}
+	if reg1.Generation() != 2 || reg2.Generation() != 2 {
+		t.Errorf("expected generation 2, got %d and %d", reg1.Generation(), reg2.Generation())
+	}
+
+	reg1.Reset()
+	if reg2.Len() != 0 {
+		t.Errorf("expected length 0, got %d", reg2.Len())
+	}
+}
+
+func TestSharedFileRegistry_ConcurrentWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reg.json")
+	regs := []*goreg.SharedFileRegistry[int]{
+		openSharedFileRegistry(t, path),
+		openSharedFileRegistry(t, path),
+		openSharedFileRegistry(t, path),
+	}
+
+	var wg sync.WaitGroup
+	for i, reg := range regs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 10 {
+				reg.Register(fmt.Sprintf("%d-%d", i, j), j)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, reg := range regs {
+		if reg.Len() != 30 {
+			t.Errorf("expected length 30, got %d", reg.Len())
+		}
+	}
+}
+
+func TestSharedFileRegistry_FailedWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reg.json")
+	var errs int
+	reg, err := goreg.OpenSharedFileRegistry[float64](goreg.NewStandardRegistry[float64](), path, &goreg.SharedFileOptions{
+		OnError: func(error) { errs++ },
+	})
+	if err != nil {
+		t.Fatalf("failed to open registry: %v", err)
+	}
+	defer reg.Close()
+
+	reg.Register("kajsmentke", 42)
+	// NaN can't be encoded as JSON, so writing the file fails.
+	reg.Register("kozmeker", math.NaN())
+	if errs != 1 {
+		t.Errorf("expected 1 error, got %d", errs)
+	}
+
+	if _, ok := reg.Get("kozmeker"); ok {
+		t.Error("expected key kozmeker to be not found after the failed write")
+	}
+	if val, ok := reg.Get("kajsmentke"); !ok || val != 42 {
+		t.Errorf("expected 42, got %v", val)
+	}
+}
@@ -8,14 +8,14 @@ import (
 	"iter"
 	"log/slog"
 	"regexp"
-	"sync"
 )
 
 // StandardRegistry is a standard registry. It uses a map under the hood.
 type StandardRegistry[T any] struct {
 	objs     map[string]T
 	stringRe *regexp.Regexp
-	mu       sync.RWMutex
+	limits   DecodeLimits
+	mu       statsMutex
 }
 
 // NewStandardRegistry creates a new [StandardRegistry].
@@ -84,6 +84,11 @@ func (r *StandardRegistry[T]) Iter() iter.Seq2[string, T] {
 	}
 }
 
+// LockStats returns the lock-contention statistics of the registry.
+func (r *StandardRegistry[T]) LockStats() LockStats {
+	return r.mu.stats()
+}
+
 // String returns a string representation of the registry.
 func (r *StandardRegistry[T]) String() string {
 	return r.stringRe.FindString(fmt.Sprintf("%#v", r.objs))
@@ -96,11 +101,28 @@ func (r *StandardRegistry[T]) MarshalJSON() ([]byte, error) {
 	return json.Marshal(r.objs)
 }
 
+// SetDecodeLimits sets the limits enforced by UnmarshalJSON and GobDecode.
+func (r *StandardRegistry[T]) SetDecodeLimits(limits DecodeLimits) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.limits = limits
+}
+
 // UnmarshalJSON implements the [encoding/json.Unmarshaler] interface.
+// The decoded objects are merged into the registry. Use [DecodeWith] to pick the load semantics explicitly.
+// If decoding fails, the registry is left untouched.
 func (r *StandardRegistry[T]) UnmarshalJSON(data []byte) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	return json.Unmarshal(data, &r.objs)
+
+	if err := r.limits.checkData(data, true); err != nil {
+		return err
+	}
+	objs := make(map[string]T)
+	if err := json.Unmarshal(data, &objs); err != nil {
+		return err
+	}
+	return r.merge(objs)
 }
 
 // GobEncode implements the [encoding/gob.GobEncoder] interface.
@@ -117,8 +139,36 @@ func (r *StandardRegistry[T]) GobEncode() ([]byte, error) {
 }
 
 // GobDecode implements the [encoding/gob.GobDecoder] interface.
+// The decoded objects are merged into the registry. If decoding fails, the registry is left untouched.
 func (r *StandardRegistry[T]) GobDecode(data []byte) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	return gob.NewDecoder(bytes.NewReader(data)).Decode(&r.objs)
+
+	if err := r.limits.checkData(data, false); err != nil {
+		return err
+	}
+	objs := make(map[string]T)
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&objs); err != nil {
+		return err
+	}
+	return r.merge(objs)
+}
+
+// merge checks the decoded objects against the decode limits and merges them into the registry.
+func (r *StandardRegistry[T]) merge(objs map[string]T) error {
+	i := 0
+	for id := range objs {
+		if err := r.limits.checkEntry(i, id); err != nil {
+			return err
+		}
+		i++
+	}
+
+	if r.objs == nil {
+		r.objs = make(map[string]T, len(objs))
+	}
+	for id, obj := range objs {
+		r.objs[id] = obj
+	}
+	return nil
 }
@@ -0,0 +1,472 @@
+package goreg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/maphash"
+	"io"
+	"io/fs"
+	"iter"
+	"log/slog"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Store is a storage backend for a [StoreRegistry].
+// Implementations must be safe for concurrent use.
+type Store[T any] interface {
+	// Load loads the object under the ID. ok is false if the object does not exist.
+	Load(id string) (obj T, ok bool, err error)
+
+	// Save saves an object under the ID.
+	Save(id string, obj T) error
+
+	// Delete deletes the object under the ID. Deleting a nonexistent object is not an error.
+	Delete(id string) error
+
+	// List returns the IDs of all stored objects.
+	List() ([]string, error)
+}
+
+// WriteMode controls when a [StoreRegistry] writes mutations to its [Store].
+type WriteMode int
+
+const (
+	// WriteThrough writes every mutation to the store before returning.
+	WriteThrough WriteMode = iota
+
+	// WriteBehind queues mutations and writes them to the store in the background.
+	WriteBehind
+)
+
+// StoreOptions configures a [StoreRegistry].
+type StoreOptions struct {
+	// Mode is the write mode. Defaults to [WriteThrough].
+	Mode WriteMode
+
+	// OnError is called when the store returns an error. Defaults to logging the error with [log/slog].
+	OnError func(err error)
+}
+
+// idLocks serializes the mutations and read-through loads of every ID.
+// IDs are hashed onto a fixed set of mutexes, so unrelated IDs may share one.
+type idLocks struct {
+	seed maphash.Seed
+	mus  [64]sync.Mutex
+}
+
+func (l *idLocks) lock(id string) (unlock func()) {
+	mu := &l.mus[maphash.String(l.seed, id)%uint64(len(l.mus))]
+	mu.Lock()
+	return mu.Unlock
+}
+
+type pendingWrite[T any] struct {
+	obj     T
+	deleted bool
+	seq     uint64
+}
+
+// StoreRegistry is a read-through registry backed by a [Store].
+// Misses in Get fall through to the store and the loaded objects are cached.
+// Mutations are written to the store according to the [WriteMode].
+type StoreRegistry[T any] struct {
+	store Store[T]
+	cache *StandardRegistry[T]
+	opts  StoreOptions
+
+	ids     idLocks    // ties the order of cache updates to the order of store writes
+	flushMu sync.Mutex // serializes flushes so that older writes never land after newer ones
+
+	mu      sync.Mutex
+	pending map[string]pendingWrite[T]
+	seq     uint64
+	wake    chan struct{}
+	done    chan struct{}
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+// NewStoreRegistry creates a new [StoreRegistry] backed by store.
+// When using [WriteBehind], call Close to write the queued mutations and stop the background writer.
+//
+// A nil opts is equivalent to a zero [StoreOptions].
+func NewStoreRegistry[T any](store Store[T], opts *StoreOptions) *StoreRegistry[T] {
+	r := &StoreRegistry[T]{
+		store:   store,
+		cache:   NewStandardRegistry[T](),
+		pending: make(map[string]pendingWrite[T]),
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	r.ids.seed = maphash.MakeSeed()
+	if opts != nil {
+		r.opts = *opts
+	}
+	if r.opts.OnError == nil {
+		r.opts.OnError = func(err error) {
+			slog.Error("*goreg.StoreRegistry: store error", "err", err)
+		}
+	}
+
+	if r.opts.Mode == WriteBehind {
+		r.wg.Add(1)
+		go r.writer()
+	}
+
+	return r
+}
+
+func (r *StoreRegistry[T]) writer() {
+	defer r.wg.Done()
+	for {
+		select {
+		case <-r.wake:
+			if err := r.Flush(); err != nil {
+				r.opts.OnError(err)
+			}
+		case <-r.done:
+			return
+		}
+	}
+}
+
+func (r *StoreRegistry[T]) write(id string, w pendingWrite[T]) {
+	if r.opts.Mode == WriteBehind {
+		r.mu.Lock()
+		if !r.closed {
+			r.seq++
+			w.seq = r.seq
+			r.pending[id] = w
+			r.mu.Unlock()
+
+			select {
+			case r.wake <- struct{}{}:
+			default:
+			}
+			return
+		}
+		r.mu.Unlock()
+
+		// The background writer is stopped, so write synchronously, superseding any queued write of the ID.
+		r.flushMu.Lock()
+		defer r.flushMu.Unlock()
+		r.mu.Lock()
+		delete(r.pending, id)
+		r.mu.Unlock()
+	}
+
+	if err := r.flushOne(id, w); err != nil {
+		r.opts.OnError(err)
+	}
+}
+
+func (r *StoreRegistry[T]) flushOne(id string, w pendingWrite[T]) error {
+	if w.deleted {
+		if err := r.store.Delete(id); err != nil {
+			return fmt.Errorf("goreg: deleting %q from store: %w", id, err)
+		}
+		return nil
+	}
+
+	if err := r.store.Save(id, w.obj); err != nil {
+		return fmt.Errorf("goreg: saving %q to store: %w", id, err)
+	}
+	return nil
+}
+
+// Flush writes all queued mutations to the store. It is a no-op in [WriteThrough] mode.
+func (r *StoreRegistry[T]) Flush() error {
+	r.flushMu.Lock()
+	defer r.flushMu.Unlock()
+
+	r.mu.Lock()
+	pending := maps.Clone(r.pending)
+	r.mu.Unlock()
+
+	var errs []error
+	for id, w := range pending {
+		if err := r.flushOne(id, w); err != nil {
+			errs = append(errs, err)
+		}
+
+		// Keep the write queued if it was superseded while flushing.
+		r.mu.Lock()
+		if cur, ok := r.pending[id]; ok && cur.seq == w.seq {
+			delete(r.pending, id)
+		}
+		r.mu.Unlock()
+	}
+
+	return errors.Join(errs...)
+}
+
+// Close stops the background writer and writes all queued mutations to the store.
+// Mutations after Close are written to the store synchronously, like in [WriteThrough] mode.
+func (r *StoreRegistry[T]) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	r.mu.Unlock()
+
+	close(r.done)
+	r.wg.Wait()
+
+	return r.Flush()
+}
+
+// Register registers an object under the ID.
+func (r *StoreRegistry[T]) Register(id string, obj T) {
+	defer r.ids.lock(id)()
+	r.write(id, pendingWrite[T]{obj: obj})
+	r.cache.Register(id, obj)
+}
+
+// Unregister unregisters an object under the ID.
+func (r *StoreRegistry[T]) Unregister(id string) {
+	defer r.ids.lock(id)()
+	r.write(id, pendingWrite[T]{deleted: true})
+	r.cache.Unregister(id)
+}
+
+// Get returns the object under the ID, loading it from the store if it is not cached.
+func (r *StoreRegistry[T]) Get(id string) (obj T, ok bool) {
+	if obj, ok := r.cache.Get(id); ok {
+		return obj, true
+	}
+
+	// Loading under the ID lock keeps a concurrent Unregister from deleting the object
+	// between the load and caching it, which would cache it for good.
+	defer r.ids.lock(id)()
+	if obj, ok := r.cache.Get(id); ok {
+		return obj, true
+	}
+
+	r.mu.Lock()
+	w, queued := r.pending[id]
+	r.mu.Unlock()
+	if queued && w.deleted {
+		return obj, false
+	}
+
+	obj, ok, err := r.store.Load(id)
+	if err != nil {
+		r.opts.OnError(fmt.Errorf("goreg: loading %q from store: %w", id, err))
+		return obj, false
+	}
+	if ok {
+		r.cache.Register(id, obj)
+	}
+
+	return obj, ok
+}
+
+// MustGet returns the object under the ID and logs error if not found.
+func (r *StoreRegistry[T]) MustGet(id string) T {
+	obj, ok := r.Get(id)
+	if !ok {
+		slog.Error("*goreg.StoreRegistry: object not found", "id", id)
+	}
+	return obj
+}
+
+// listIDs returns the IDs of all objects, including the ones whose writes are still queued.
+func (r *StoreRegistry[T]) listIDs() []string {
+	ids, err := r.store.List()
+	if err != nil {
+		r.opts.OnError(fmt.Errorf("goreg: listing store: %w", err))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.pending) == 0 {
+		return ids
+	}
+
+	ids = slices.DeleteFunc(ids, func(id string) bool {
+		_, queued := r.pending[id]
+		return queued
+	})
+	for id, w := range r.pending {
+		if !w.deleted {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	return ids
+}
+
+// Len returns the number of items in the store.
+func (r *StoreRegistry[T]) Len() int {
+	return len(r.listIDs())
+}
+
+// Reset wipes the registry and deletes every object from the store.
+func (r *StoreRegistry[T]) Reset() {
+	for _, id := range r.listIDs() {
+		unlock := r.ids.lock(id)
+		r.write(id, pendingWrite[T]{deleted: true})
+		r.cache.Unregister(id)
+		unlock()
+	}
+}
+
+// Iter returns an iterator over key-value pairs in the store. See the [iter] package documentation for more details.
+//
+// Unlike the iterators of the in-memory registries, it does not hold a lock,
+// so other methods may be called in the for loop.
+func (r *StoreRegistry[T]) Iter() iter.Seq2[string, T] {
+	return func(yield func(string, T) bool) {
+		for _, id := range r.listIDs() {
+			obj, ok := r.Get(id)
+			if !ok {
+				continue
+			}
+			if !yield(id, obj) {
+				return
+			}
+		}
+	}
+}
+
+// String returns a string representation of the cached part of the registry.
+func (r *StoreRegistry[T]) String() string {
+	return r.cache.String()
+}
+
+// MemoryStore is an in-memory [Store].
+type MemoryStore[T any] struct {
+	objs map[string]T
+	mu   sync.RWMutex
+}
+
+// NewMemoryStore creates a new [MemoryStore].
+func NewMemoryStore[T any]() *MemoryStore[T] {
+	return &MemoryStore[T]{objs: make(map[string]T)}
+}
+
+// Load loads the object under the ID.
+func (s *MemoryStore[T]) Load(id string) (obj T, ok bool, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	obj, ok = s.objs[id]
+	return
+}
+
+// Save saves an object under the ID.
+func (s *MemoryStore[T]) Save(id string, obj T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objs[id] = obj
+	return nil
+}
+
+// Delete deletes the object under the ID.
+func (s *MemoryStore[T]) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objs, id)
+	return nil
+}
+
+// List returns the sorted IDs of all stored objects.
+func (s *MemoryStore[T]) List() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]string, 0, len(s.objs))
+	for id := range s.objs {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	return ids, nil
+}
+
+const dirStoreExt = ".json"
+
+// DirStore is a [Store] that keeps every object in its own JSON file in a directory.
+// File names are the path-escaped IDs. Files are written atomically.
+type DirStore[T any] struct {
+	dir string
+}
+
+// NewDirStore creates a new [DirStore] in dir, creating the directory if needed.
+func NewDirStore[T any](dir string) (*DirStore[T], error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("goreg: creating store directory: %w", err)
+	}
+	return &DirStore[T]{dir: dir}, nil
+}
+
+func (s *DirStore[T]) path(id string) string {
+	return filepath.Join(s.dir, url.PathEscape(id)+dirStoreExt)
+}
+
+// Load loads the object under the ID.
+func (s *DirStore[T]) Load(id string) (obj T, ok bool, err error) {
+	data, err := os.ReadFile(s.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return obj, false, nil
+	}
+	if err != nil {
+		return obj, false, err
+	}
+
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return obj, false, fmt.Errorf("%w: %w", ErrCorrupt, err)
+	}
+
+	return obj, true, nil
+}
+
+// Save saves an object under the ID.
+func (s *DirStore[T]) Save(id string, obj T) error {
+	return writeFileAtomic(s.path(id), 0o644, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(obj)
+	})
+}
+
+// Delete deletes the object under the ID.
+func (s *DirStore[T]) Delete(id string) error {
+	if err := os.Remove(s.path(id)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// List returns the sorted IDs of all stored objects.
+func (s *DirStore[T]) List() ([]string, error) {
+	des, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, de := range des {
+		name := de.Name()
+		if de.IsDir() || !strings.HasSuffix(name, dirStoreExt) {
+			continue
+		}
+
+		id, err := url.PathUnescape(strings.TrimSuffix(name, dirStoreExt))
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	return ids, nil
+}
@@ -0,0 +1,210 @@
+package goreg_test
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/goreg"
+)
+
+func TestStoreRegistry_ReadThrough(t *testing.T) {
+	store := goreg.NewMemoryStore[int]()
+	store.Save("kajsmentke", 42)
+
+	reg := goreg.NewStoreRegistry[int](store, nil)
+
+	if val, ok := reg.Get("kajsmentke"); !ok || val != 42 {
+		t.Errorf("expected 42, got %v", val)
+	}
+	if _, ok := reg.Get("invalid"); ok {
+		t.Error("expected key to be not found")
+	}
+
+	// The loaded object is cached.
+	store.Delete("kajsmentke")
+	if val, ok := reg.Get("kajsmentke"); !ok || val != 42 {
+		t.Errorf("expected cached 42, got %v", val)
+	}
+}
+
+func TestStoreRegistry_WriteThrough(t *testing.T) {
+	store := goreg.NewMemoryStore[int]()
+	reg := goreg.NewStoreRegistry[int](store, nil)
+
+	reg.Register("kajsmentke", 42)
+	reg.Register("kozmeker", 69)
+
+	if val, ok, _ := store.Load("kajsmentke"); !ok || val != 42 {
+		t.Errorf("expected 42 in store, got %v", val)
+	}
+
+	reg.Unregister("kajsmentke")
+	if _, ok, _ := store.Load("kajsmentke"); ok {
+		t.Error("expected key kajsmentke to be deleted from store")
+	}
+
+	if reg.Len() != 1 {
+		t.Errorf("expected length 1, got %d", reg.Len())
+	}
+
+	reg.Reset()
+	if ids, _ := store.List(); len(ids) != 0 {
+		t.Errorf("expected empty store, got %v", ids)
+	}
+}
+
+func TestStoreRegistry_WriteBehind(t *testing.T) {
+	store := goreg.NewMemoryStore[int]()
+	store.Save("a", 1)
+
+	reg := goreg.NewStoreRegistry[int](store, &goreg.StoreOptions{Mode: goreg.WriteBehind})
+	reg.Register("kajsmentke", 42)
+	reg.Register("kozmeker", 69)
+	reg.Unregister("a")
+
+	if _, ok := reg.Get("a"); ok {
+		t.Error("expected key a to be not found")
+	}
+	if reg.Len() != 2 {
+		t.Errorf("expected length 2, got %d", reg.Len())
+	}
+
+	if err := reg.Close(); err != nil {
+		t.Fatalf("failed to close registry: %v", err)
+	}
+
+	ids, _ := store.List()
+	if expected := []string{"kajsmentke", "kozmeker"}; !slices.Equal(ids, expected) {
+		t.Errorf("expected %v, got %v", expected, ids)
+	}
+}
+
+func TestStoreRegistry_WriteBehindAfterClose(t *testing.T) {
+	store := goreg.NewMemoryStore[int]()
+	reg := goreg.NewStoreRegistry[int](store, &goreg.StoreOptions{Mode: goreg.WriteBehind})
+	reg.Register("kajsmentke", 42)
+	if err := reg.Close(); err != nil {
+		t.Fatalf("failed to close registry: %v", err)
+	}
+
+	reg.Register("kozmeker", 69)
+	reg.Unregister("kajsmentke")
+
+	if val, ok, _ := store.Load("kozmeker"); !ok || val != 69 {
+		t.Errorf("expected 69 in store, got %v", val)
+	}
+	if _, ok, _ := store.Load("kajsmentke"); ok {
+		t.Error("expected key kajsmentke to be deleted from store")
+	}
+}
+
+// blockingStore blocks the first Load until release is closed.
+type blockingStore struct {
+	*goreg.MemoryStore[int]
+	once    sync.Once
+	loading chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingStore) Load(id string) (int, bool, error) {
+	obj, ok, err := s.MemoryStore.Load(id)
+	s.once.Do(func() {
+		close(s.loading)
+		<-s.release
+	})
+	return obj, ok, err
+}
+
+func TestStoreRegistry_UnregisterDuringLoad(t *testing.T) {
+	store := &blockingStore{
+		MemoryStore: goreg.NewMemoryStore[int](),
+		loading:     make(chan struct{}),
+		release:     make(chan struct{}),
+	}
+	store.Save("kajsmentke", 42)
+
+	reg := goreg.NewStoreRegistry[int](store, nil)
+
+	got := make(chan struct{})
+	go func() {
+		reg.Get("kajsmentke")
+		close(got)
+	}()
+	<-store.loading
+
+	unregistered := make(chan struct{})
+	go func() {
+		reg.Unregister("kajsmentke")
+		close(unregistered)
+	}()
+
+	select {
+	case <-unregistered:
+		t.Error("expected Unregister to wait for the load")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(store.release)
+	<-got
+	<-unregistered
+
+	if _, ok := reg.Get("kajsmentke"); ok {
+		t.Error("expected key kajsmentke to be not found")
+	}
+}
+
+func TestStoreRegistry_Iter(t *testing.T) {
+	store := goreg.NewMemoryStore[int]()
+	store.Save("kajsmentke", 42)
+	store.Save("kozmeker", 69)
+
+	reg := goreg.NewStoreRegistry[int](store, nil)
+
+	values := goreg.Collect(reg)
+	if values["kajsmentke"] != 42 {
+		t.Errorf("expected 42, got %d", values["kajsmentke"])
+	}
+	if values["kozmeker"] != 69 {
+		t.Errorf("expected 69, got %d", values["kozmeker"])
+	}
+}
+
+func TestDirStore(t *testing.T) {
+	store, err := goreg.NewDirStore[int](t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	if err := store.Save("kajsmentke", 42); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+	if err := store.Save("mods/kozmeker", 69); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+
+	if val, ok, err := store.Load("mods/kozmeker"); err != nil || !ok || val != 69 {
+		t.Errorf("expected 69, got %v (ok=%v, err=%v)", val, ok, err)
+	}
+	if _, ok, err := store.Load("invalid"); err != nil || ok {
+		t.Errorf("expected key to be not found (ok=%v, err=%v)", ok, err)
+	}
+
+	ids, err := store.List()
+	if err != nil {
+		t.Fatalf("failed to list: %v", err)
+	}
+	if expected := []string{"kajsmentke", "mods/kozmeker"}; !slices.Equal(ids, expected) {
+		t.Errorf("expected %v, got %v", expected, ids)
+	}
+
+	if err := store.Delete("kajsmentke"); err != nil {
+		t.Errorf("failed to delete: %v", err)
+	}
+	if err := store.Delete("kajsmentke"); err != nil {
+		t.Errorf("failed to delete twice: %v", err)
+	}
+	if ids, _ := store.List(); len(ids) != 1 {
+		t.Errorf("expected 1 ID, got %v", ids)
+	}
+}
@@ -0,0 +1,117 @@
+package goreg
+
+import (
+	"bufio"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The streaming functions encode and decode one entry at a time instead of building the whole encoding in memory.
+// While encoding, the registry is only locked to copy its entries in a single pass of Iter, so writers are not stalled
+// for the whole encode. The copy is shallow: only the entries are copied, not what the objects point to.
+
+// WriteJSON writes the objects in reg to w in the JSON array format of [JSONCodec], one entry at a time.
+// It returns the number of bytes written.
+func WriteJSON[T any](w io.Writer, reg Registry[T]) (n int64, err error) {
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+
+	bw.WriteByte('[')
+	first := true
+	for _, e := range Entries(reg) {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return cw.n, fmt.Errorf("goreg: encoding %q: %w", e.Key, err)
+		}
+
+		if !first {
+			bw.WriteByte(',')
+		}
+		first = false
+		if _, err := bw.Write(data); err != nil {
+			return cw.n, err
+		}
+	}
+	bw.WriteString("]\n")
+
+	err = bw.Flush()
+	return cw.n, err
+}
+
+// ReadJSON reads entries from r in either the array format of [JSONCodec] or the object format of [JSONObjectCodec]
+// and registers them in reg as soon as they are decoded. Existing objects in reg are kept unless overwritten.
+// If an error occurs, the entries decoded up to that point stay registered.
+// It returns the number of bytes read.
+func ReadJSON[T any](r io.Reader, reg Registry[T]) (n int64, err error) {
+	cr := &countingReader{r: r}
+	dec := json.NewDecoder(cr)
+
+	err = decodeJSONStream(dec, func(e Entry[T], _ int64) error {
+		reg.Register(e.Key, e.Value)
+		return nil
+	})
+	return cr.n, err
+}
+
+// WriteGob writes the objects in reg to w as a stream of gob-encoded entries, one entry at a time.
+// The stream can be read with [ReadGob]; it is not the format of [GobCodec].
+// It returns the number of bytes written.
+func WriteGob[T any](w io.Writer, reg Registry[T]) (n int64, err error) {
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	enc := gob.NewEncoder(bw)
+
+	for _, e := range Entries(reg) {
+		if err := enc.Encode(e); err != nil {
+			return cw.n, fmt.Errorf("goreg: encoding %q: %w", e.Key, err)
+		}
+	}
+
+	err = bw.Flush()
+	return cw.n, err
+}
+
+// ReadGob reads a stream written by [WriteGob] from r and registers the entries in reg as soon as they are decoded.
+// Existing objects in reg are kept unless overwritten.
+// If an error occurs, the entries decoded up to that point stay registered.
+// It returns the number of bytes read.
+func ReadGob[T any](r io.Reader, reg Registry[T]) (n int64, err error) {
+	cr := &countingReader{r: r}
+	dec := gob.NewDecoder(cr)
+
+	for {
+		var e Entry[T]
+		if err := dec.Decode(&e); err != nil {
+			if errors.Is(err, io.EOF) {
+				return cr.n, nil
+			}
+			return cr.n, err
+		}
+		reg.Register(e.Key, e.Value)
+	}
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,148 @@
+package goreg_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/MatusOllah/goreg"
+)
+
+func TestWriteJSON(t *testing.T) {
+	reg := goreg.NewOrderedRegistry[int]()
+	reg.Register("kozmeker", 69)
+	reg.Register("kajsmentke", 42)
+
+	var bf bytes.Buffer
+	n, err := goreg.WriteJSON(&bf, reg)
+	if err != nil {
+		t.Fatalf("failed to write JSON: %v", err)
+	}
+	if n != int64(bf.Len()) {
+		t.Errorf("expected %d bytes written, got %d", bf.Len(), n)
+	}
+
+	expected := `[{"key":"kozmeker","value":69},{"key":"kajsmentke","value":42}]` + "\n"
+	if bf.String() != expected {
+		t.Errorf("expected %s, got %s", expected, bf.String())
+	}
+
+	newReg := goreg.NewOrderedRegistry[int]()
+	if _, err := goreg.ReadJSON(&bf, newReg); err != nil {
+		t.Fatalf("failed to read JSON: %v", err)
+	}
+	if newReg.String() != reg.String() {
+		t.Errorf("expected %s, got %s", reg, newReg)
+	}
+}
+
+func TestWriteJSON_DuplicateIDs(t *testing.T) {
+	reg := goreg.NewOrderedRegistry[int]()
+	reg.Register("kajsmentke", 42)
+	reg.Register("kozmeker", 69)
+	reg.Register("kajsmentke", 43)
+
+	var bf bytes.Buffer
+	if _, err := goreg.WriteJSON(&bf, reg); err != nil {
+		t.Fatalf("failed to write JSON: %v", err)
+	}
+
+	expected := `[{"key":"kajsmentke","value":42},{"key":"kozmeker","value":69},{"key":"kajsmentke","value":43}]` + "\n"
+	if bf.String() != expected {
+		t.Errorf("expected %s, got %s", expected, bf.String())
+	}
+}
+
+func TestWriteJSON_Empty(t *testing.T) {
+	var bf bytes.Buffer
+	if _, err := goreg.WriteJSON(&bf, goreg.NewStandardRegistry[int]()); err != nil {
+		t.Fatalf("failed to write JSON: %v", err)
+	}
+	if expected := "[]\n"; bf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, bf.String())
+	}
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+func TestWriteJSON_ConcurrentWrites(t *testing.T) {
+	reg := goreg.NewStandardRegistry[string]()
+	for i := range 1000 {
+		reg.Register(fmt.Sprint(i), strings.Repeat("x", 100))
+	}
+
+	// Registering while the output is being written must not deadlock.
+	w := writerFunc(func(p []byte) (int, error) {
+		reg.Register("written", "")
+		return len(p), nil
+	})
+	if _, err := goreg.WriteJSON(w, reg); err != nil {
+		t.Fatalf("failed to write JSON: %v", err)
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	reg := goreg.NewOrderedRegistry[int]()
+	reg.Register("existing", 1)
+
+	data := `{"kozmeker":69,"kajsmentke":42}`
+	n, err := goreg.ReadJSON(strings.NewReader(data), reg)
+	if err != nil {
+		t.Fatalf("failed to read JSON: %v", err)
+	}
+	if n != int64(len(data)) {
+		t.Errorf("expected %d bytes read, got %d", len(data), n)
+	}
+
+	expected := `[{existing 1} {kozmeker 69} {kajsmentke 42}]`
+	if reg.String() != expected {
+		t.Errorf("expected %s, got %s", expected, reg)
+	}
+
+	if _, err := goreg.ReadJSON(strings.NewReader(`[{"key":"a","value":1},`), reg); err == nil {
+		t.Error("expected error for truncated input")
+	}
+}
+
+func TestWriteGob(t *testing.T) {
+	reg := goreg.NewOrderedRegistry[int]()
+	reg.Register("kozmeker", 69)
+	reg.Register("kajsmentke", 42)
+
+	var bf bytes.Buffer
+	n, err := goreg.WriteGob(&bf, reg)
+	if err != nil {
+		t.Fatalf("failed to write gob: %v", err)
+	}
+	if n != int64(bf.Len()) {
+		t.Errorf("expected %d bytes written, got %d", bf.Len(), n)
+	}
+
+	newReg := goreg.NewOrderedRegistry[int]()
+	if _, err := goreg.ReadGob(&bf, newReg); err != nil {
+		t.Fatalf("failed to read gob: %v", err)
+	}
+	if newReg.String() != reg.String() {
+		t.Errorf("expected %s, got %s", reg, newReg)
+	}
+}
+
+func TestReadGob_Truncated(t *testing.T) {
+	reg := goreg.NewOrderedRegistry[int]()
+	reg.Register("kozmeker", 69)
+	reg.Register("kajsmentke", 42)
+
+	var bf bytes.Buffer
+	if _, err := goreg.WriteGob(&bf, reg); err != nil {
+		t.Fatalf("failed to write gob: %v", err)
+	}
+
+	if _, err := goreg.ReadGob(bytes.NewReader(bf.Bytes()[:bf.Len()-2]), goreg.NewOrderedRegistry[int]()); err == nil {
+		t.Error("expected error for truncated input")
+	}
+}
@@ -0,0 +1,360 @@
+package goreg
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+const syncVersion = 1
+
+// SyncPolicy decides what a client does when its registry doesn't match the server's.
+type SyncPolicy int
+
+const (
+	// SyncStrict rejects the session if the client is missing entries, has extra entries or has different values.
+	// This is the default.
+	SyncStrict SyncPolicy = iota
+
+	// SyncAdopt makes the client adopt the contents of the server:
+	// missing and changed entries are registered with the server's values and extra entries are unregistered.
+	SyncAdopt
+)
+
+// SyncOptions configures [Sync].
+type SyncOptions struct {
+	// Policy decides what to do when the registries don't match. Defaults to [SyncStrict].
+	Policy SyncPolicy
+
+	// Partial makes the client send a digest of every entry, so that the server only sends the entries that differ
+	// instead of its whole contents.
+	Partial bool
+}
+
+// SyncReport describes how a client registry differs from the server registry.
+type SyncReport struct {
+	// Missing lists the IDs registered on the server, but not on the client.
+	Missing []string `json:"missing,omitempty"`
+
+	// Extra lists the IDs registered on the client, but not on the server.
+	Extra []string `json:"extra,omitempty"`
+
+	// Changed lists the IDs registered on both with different values.
+	Changed []string `json:"changed,omitempty"`
+
+	// Remapped lists the IDs whose raw ID on the client was changed to match the server.
+	Remapped []string `json:"remapped,omitempty"`
+}
+
+// Mismatch reports whether the registries differ in contents.
+func (r *SyncReport) Mismatch() bool {
+	return len(r.Missing) > 0 || len(r.Extra) > 0 || len(r.Changed) > 0
+}
+
+// String returns a human-readable description of the differences.
+func (r *SyncReport) String() string {
+	if !r.Mismatch() {
+		return "registries match"
+	}
+
+	var parts []string
+	for _, l := range []struct {
+		name string
+		ids  []string
+	}{{"missing", r.Missing}, {"extra", r.Extra}, {"changed", r.Changed}} {
+		if len(l.ids) > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s (%s)", len(l.ids), l.name, strings.Join(l.ids, ", ")))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// SyncMismatchError is returned by [Sync] and [ServeSync] when the client rejects the session.
+type SyncMismatchError struct {
+	Report SyncReport
+}
+
+// Error implements the error interface.
+func (e *SyncMismatchError) Error() string {
+	return "goreg: registry mismatch: " + e.Report.String()
+}
+
+// syncHello is sent by the client to start a session.
+type syncHello struct {
+	Version     int               `json:"version"`
+	Fingerprint []byte            `json:"fingerprint"`
+	Digests     map[string][]byte `json:"digests,omitempty"`
+}
+
+// syncEntry is an entry sent by the server.
+type syncEntry struct {
+	ID    string          `json:"id"`
+	Value json.RawMessage `json:"value"`
+}
+
+// syncState is the reply of the server.
+type syncState struct {
+	Error   string      `json:"error,omitempty"`
+	Match   bool        `json:"match,omitempty"`
+	RawIDs  RawIDMap    `json:"rawIds,omitempty"`
+	Entries []syncEntry `json:"entries,omitempty"`
+}
+
+// syncResult is sent by the client to end the session.
+type syncResult struct {
+	OK     bool        `json:"ok"`
+	Report *SyncReport `json:"report,omitempty"`
+}
+
+// ServeSync runs the server side of a sync session over rw.
+//
+// The client sends a fingerprint of its registry, covering the IDs, raw IDs and values.
+// If it matches the fingerprint of reg, the session ends right away. Otherwise, the server sends its raw IDs
+// along with its entries, or only the ones that differ if the client asked for a partial sync.
+// The client then remaps its raw IDs to match or rejects the session, in which case a [*SyncMismatchError] is returned.
+//
+// Every message is a length-prefixed, checksummed frame holding a JSON object. Values are encoded as JSON.
+func ServeSync[T any](rw io.ReadWriter, reg *RawIDRegistry[T]) error {
+	var hello syncHello
+	if err := readSyncMessage(rw, &hello); err != nil {
+		return err
+	}
+	if hello.Version != syncVersion {
+		writeSyncMessage(rw, syncState{Error: fmt.Sprintf("unsupported sync version %d", hello.Version)})
+		return fmt.Errorf("%w: sync version %d", ErrUnsupportedVersion, hello.Version)
+	}
+
+	entries, raws, err := syncSnapshot(reg)
+	if err != nil {
+		return err
+	}
+
+	if bytes.Equal(hello.Fingerprint, syncFingerprint(entries, raws)) {
+		return writeSyncMessage(rw, syncState{Match: true})
+	}
+
+	state := syncState{RawIDs: raws, Entries: []syncEntry{}}
+	for _, e := range entries {
+		if hello.Digests != nil && bytes.Equal(hello.Digests[e.ID], syncDigest(e)) {
+			continue
+		}
+		state.Entries = append(state.Entries, e)
+	}
+	if err := writeSyncMessage(rw, state); err != nil {
+		return err
+	}
+
+	var result syncResult
+	if err := readSyncMessage(rw, &result); err != nil {
+		return err
+	}
+	if !result.OK {
+		if result.Report == nil {
+			return errors.New("goreg: sync aborted by client")
+		}
+		return &SyncMismatchError{Report: *result.Report}
+	}
+	return nil
+}
+
+// Sync runs the client side of a sync session over rw against a server running [ServeSync].
+//
+// On success, the raw IDs of reg match the server and, with [SyncAdopt], so do its contents.
+// The returned report describes what differed. If the session is rejected, the error is a [*SyncMismatchError].
+//
+// A nil opts is equivalent to a zero [SyncOptions].
+func Sync[T any](rw io.ReadWriter, reg *RawIDRegistry[T], opts *SyncOptions) (*SyncReport, error) {
+	var o SyncOptions
+	if opts != nil {
+		o = *opts
+	}
+
+	entries, raws, err := syncSnapshot(reg)
+	if err != nil {
+		return nil, err
+	}
+
+	hello := syncHello{Version: syncVersion, Fingerprint: syncFingerprint(entries, raws)}
+	local := make(map[string]syncEntry, len(entries))
+	for _, e := range entries {
+		local[e.ID] = e
+	}
+	if o.Partial {
+		hello.Digests = make(map[string][]byte, len(entries))
+		for _, e := range entries {
+			hello.Digests[e.ID] = syncDigest(e)
+		}
+	}
+	if err := writeSyncMessage(rw, hello); err != nil {
+		return nil, err
+	}
+
+	var state syncState
+	if err := readSyncMessage(rw, &state); err != nil {
+		return nil, err
+	}
+	if state.Error != "" {
+		return nil, fmt.Errorf("goreg: sync rejected by server: %s", state.Error)
+	}
+	if state.Match {
+		return &SyncReport{}, nil
+	}
+
+	report, adopt, err := diffSync(local, state, o.Partial)
+	if err != nil {
+		writeSyncMessage(rw, syncResult{OK: false})
+		return nil, err
+	}
+	if report.Mismatch() && o.Policy != SyncAdopt {
+		if err := writeSyncMessage(rw, syncResult{OK: false, Report: report}); err != nil {
+			return nil, err
+		}
+		return report, &SyncMismatchError{Report: *report}
+	}
+
+	objs := make([]Entry[T], 0, len(adopt))
+	for _, e := range adopt {
+		var obj T
+		if err := json.Unmarshal(e.Value, &obj); err != nil {
+			writeSyncMessage(rw, syncResult{OK: false})
+			return nil, fmt.Errorf("goreg: decoding %q: %w", e.ID, err)
+		}
+		objs = append(objs, Entry[T]{Key: e.ID, Value: obj})
+	}
+	for _, id := range report.Extra {
+		reg.Unregister(id)
+	}
+	for _, e := range objs {
+		reg.Register(e.Key, e.Value)
+	}
+
+	for id, raw := range state.RawIDs {
+		if old, ok := raws[id]; ok && old != raw {
+			report.Remapped = append(report.Remapped, id)
+		}
+	}
+	slices.Sort(report.Remapped)
+	if err := reg.SetRawIDs(state.RawIDs); err != nil {
+		writeSyncMessage(rw, syncResult{OK: false})
+		return nil, err
+	}
+
+	if err := writeSyncMessage(rw, syncResult{OK: true, Report: report}); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// diffSync compares the local entries with the state sent by the server and returns the differences
+// along with the server entries to adopt.
+func diffSync(local map[string]syncEntry, state syncState, partial bool) (*SyncReport, []syncEntry, error) {
+	report := &SyncReport{}
+	var adopt []syncEntry
+
+	sent := make(map[string]syncEntry, len(state.Entries))
+	for _, e := range state.Entries {
+		if _, ok := state.RawIDs[e.ID]; !ok {
+			return nil, nil, fmt.Errorf("goreg: sync: entry %q has no raw ID", e.ID)
+		}
+		sent[e.ID] = e
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(state.RawIDs)) {
+		e, ok := sent[id]
+		l, registered := local[id]
+		switch {
+		case !registered && !ok:
+			return nil, nil, fmt.Errorf("goreg: sync: server did not send missing entry %q", id)
+		case !registered:
+			report.Missing = append(report.Missing, id)
+			adopt = append(adopt, e)
+		case ok && (partial || !bytes.Equal(l.Value, e.Value)):
+			report.Changed = append(report.Changed, id)
+			adopt = append(adopt, e)
+		}
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(local)) {
+		if _, ok := state.RawIDs[id]; !ok {
+			report.Extra = append(report.Extra, id)
+		}
+	}
+
+	return report, adopt, nil
+}
+
+// syncSnapshot returns the registered entries of reg sorted by ID, with their values encoded as JSON,
+// along with their raw IDs.
+func syncSnapshot[T any](reg *RawIDRegistry[T]) ([]syncEntry, RawIDMap, error) {
+	canonical, err := canonicalEntries[T](reg)
+	if err != nil {
+		return nil, nil, err
+	}
+	sortCanonical(canonical)
+
+	entries := make([]syncEntry, 0, len(canonical))
+	raws := make(RawIDMap, len(canonical))
+	for _, e := range canonical {
+		raw, ok := reg.RawIDOf(e.Key)
+		if !ok {
+			return nil, nil, fmt.Errorf("goreg: %q has no raw ID", e.Key)
+		}
+		entries = append(entries, syncEntry{ID: e.Key, Value: e.Value})
+		raws[e.Key] = raw
+	}
+	return entries, raws, nil
+}
+
+// syncDigest returns a hash of the ID and value of e.
+func syncDigest(e syncEntry) []byte {
+	return hashCanonical([]Entry[[]byte]{{Key: e.ID, Value: e.Value}}, sha256.New)
+}
+
+// syncFingerprint returns a hash of the entries, sorted by ID, and their raw IDs.
+func syncFingerprint(entries []syncEntry, raws RawIDMap) []byte {
+	h := sha256.New()
+	canonical := make([]Entry[[]byte], 0, len(entries))
+	for _, e := range entries {
+		canonical = append(canonical, Entry[[]byte]{Key: e.ID, Value: e.Value})
+	}
+	h.Write(hashCanonical(canonical, sha256.New))
+
+	var buf [4]byte
+	for _, e := range entries {
+		binary.LittleEndian.PutUint32(buf[:], raws[e.ID])
+		h.Write(buf[:])
+	}
+	return h.Sum(nil)
+}
+
+func writeSyncMessage(w io.Writer, msg any) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := writeFrame(w, payload); err != nil {
+		return fmt.Errorf("goreg: sync: %w", err)
+	}
+	return nil
+}
+
+func readSyncMessage(r io.Reader, msg any) error {
+	payload, err := readFrame(r)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return fmt.Errorf("goreg: sync: %w", err)
+	}
+	if err := json.Unmarshal(payload, msg); err != nil {
+		return fmt.Errorf("goreg: sync: %w", err)
+	}
+	return nil
+}
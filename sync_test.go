@@ -0,0 +1,141 @@
+package goreg_test
+
+import (
+	"errors"
+	"maps"
+	"net"
+	"slices"
+	"testing"
+
+	"github.com/MatusOllah/goreg"
+)
+
+// runSync runs a sync session between server and client over a pipe and returns the results of both sides.
+func runSync(t *testing.T, server, client *goreg.RawIDRegistry[string], opts *goreg.SyncOptions) (*goreg.SyncReport, error, error) {
+	t.Helper()
+
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- goreg.ServeSync(serverConn, server)
+	}()
+
+	report, err := goreg.Sync(clientConn, client, opts)
+	return report, err, <-serverErr
+}
+
+func newSyncRegistry(ids ...string) *goreg.RawIDRegistry[string] {
+	reg := goreg.NewRawIDRegistry[string](goreg.NewStandardRegistry[string]())
+	for _, id := range ids {
+		reg.Register(id, "value of "+id)
+	}
+	return reg
+}
+
+func TestSync_Match(t *testing.T) {
+	server := newSyncRegistry("stone", "dirt")
+	client := newSyncRegistry("stone", "dirt")
+
+	report, err, serverErr := runSync(t, server, client, nil)
+	if err != nil || serverErr != nil {
+		t.Fatalf("failed to sync: %v, %v", err, serverErr)
+	}
+	if report.Mismatch() || len(report.Remapped) > 0 {
+		t.Errorf("expected empty report, got %+v", report)
+	}
+}
+
+func TestSync_Remap(t *testing.T) {
+	server := newSyncRegistry("stone", "dirt", "grass")
+	client := newSyncRegistry("grass", "stone", "dirt")
+
+	for _, partial := range []bool{false, true} {
+		report, err, serverErr := runSync(t, server, client, &goreg.SyncOptions{Partial: partial})
+		if err != nil || serverErr != nil {
+			t.Fatalf("failed to sync: %v, %v", err, serverErr)
+		}
+		if report.Mismatch() {
+			t.Errorf("expected no mismatch, got %s", report)
+		}
+		if !maps.Equal(client.RawIDs(), server.RawIDs()) {
+			t.Errorf("expected raw IDs %v, got %v", server.RawIDs(), client.RawIDs())
+		}
+	}
+}
+
+func TestSync_Reject(t *testing.T) {
+	server := newSyncRegistry("stone", "dirt", "ruby")
+	client := newSyncRegistry("stone", "dirt", "sapphire")
+	client.Register("dirt", "different dirt")
+	before := client.RawIDs()
+
+	report, err, serverErr := runSync(t, server, client, nil)
+
+	var mismatch *goreg.SyncMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("expected SyncMismatchError on the client, got %v", err)
+	}
+	if !errors.As(serverErr, &mismatch) {
+		t.Fatalf("expected SyncMismatchError on the server, got %v", serverErr)
+	}
+
+	if !slices.Equal(report.Missing, []string{"ruby"}) {
+		t.Errorf("expected missing [ruby], got %v", report.Missing)
+	}
+	if !slices.Equal(report.Extra, []string{"sapphire"}) {
+		t.Errorf("expected extra [sapphire], got %v", report.Extra)
+	}
+	if !slices.Equal(report.Changed, []string{"dirt"}) {
+		t.Errorf("expected changed [dirt], got %v", report.Changed)
+	}
+	if expected := "1 missing (ruby); 1 extra (sapphire); 1 changed (dirt)"; report.String() != expected {
+		t.Errorf("expected %q, got %q", expected, report.String())
+	}
+	if !maps.Equal(client.RawIDs(), before) {
+		t.Error("expected raw IDs to be untouched after rejecting")
+	}
+}
+
+func TestSync_Adopt(t *testing.T) {
+	server := newSyncRegistry("stone", "dirt", "ruby")
+	client := newSyncRegistry("sapphire", "dirt", "stone")
+	client.Register("dirt", "different dirt")
+
+	for _, partial := range []bool{false, true} {
+		_, err, serverErr := runSync(t, server, client, &goreg.SyncOptions{Policy: goreg.SyncAdopt, Partial: partial})
+		if err != nil || serverErr != nil {
+			t.Fatalf("failed to sync: %v, %v", err, serverErr)
+		}
+
+		if !goreg.Equal[string](client, server) {
+			t.Errorf("expected %s, got %s", server, client)
+		}
+		for id := range server.Iter() {
+			serverRaw, _ := server.RawIDOf(id)
+			clientRaw, _ := client.RawIDOf(id)
+			if serverRaw != clientRaw {
+				t.Errorf("expected raw ID %d for %s, got %d", serverRaw, id, clientRaw)
+			}
+		}
+	}
+}
+
+func TestSync_Partial(t *testing.T) {
+	server := newSyncRegistry("stone", "dirt", "grass")
+	client := newSyncRegistry("stone", "dirt", "grass")
+	client.Register("grass", "old grass")
+
+	report, err, serverErr := runSync(t, server, client, &goreg.SyncOptions{Policy: goreg.SyncAdopt, Partial: true})
+	if err != nil || serverErr != nil {
+		t.Fatalf("failed to sync: %v, %v", err, serverErr)
+	}
+	if !slices.Equal(report.Changed, []string{"grass"}) {
+		t.Errorf("expected changed [grass], got %v", report.Changed)
+	}
+	if val, _ := client.Get("grass"); val != "value of grass" {
+		t.Errorf("expected server value, got %q", val)
+	}
+}
@@ -7,6 +7,15 @@ func Collect[T any](reg Registry[T]) map[string]T {
 	return maps.Collect(reg.Iter())
 }
 
+// Entries collects key-value pairs from the registry into a new slice in iteration order and returns it.
+func Entries[T any](reg Registry[T]) []Entry[T] {
+	entries := make([]Entry[T], 0, reg.Len())
+	for id, obj := range reg.Iter() {
+		entries = append(entries, Entry[T]{Key: id, Value: obj})
+	}
+	return entries
+}
+
 // Copy copies all objects in src adding them to dst.
 // When a ID in src is already present in dst,
 // the value in dst will be overwritten by the value associated
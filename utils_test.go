@@ -1,6 +1,7 @@
 package goreg_test
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/MatusOllah/goreg"
@@ -17,6 +18,18 @@ func TestCollect(t *testing.T) {
 	}
 }
 
+func TestEntries(t *testing.T) {
+	reg := goreg.NewOrderedRegistry[int]()
+	reg.Register("kozmeker", 69)
+	reg.Register("kajsmentke", 42)
+
+	entries := goreg.Entries(reg)
+	expected := []goreg.Entry[int]{{Key: "kozmeker", Value: 69}, {Key: "kajsmentke", Value: 42}}
+	if !slices.Equal(entries, expected) {
+		t.Errorf("expected %v, got %v", expected, entries)
+	}
+}
+
 func TestCopy(t *testing.T) {
 	dst := goreg.NewStandardRegistry[int]()
 	dst.Register("kajsmentke", 42)
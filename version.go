@@ -0,0 +1,313 @@
+package goreg
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+)
+
+// FormatVersion is the version of the envelope written by [VersionedJSONCodec].
+// Input without an envelope, as written by [JSONCodec], [JSONObjectCodec] and [GobCodec], is format version 0
+// with schema version 0.
+const FormatVersion = 1
+
+// gobFormatVersion is the version of the envelope written by [VersionedGobCodec].
+// Version 1 held gob-encoded values, version 2 holds JSON-encoded values so that they can be migrated.
+const gobFormatVersion = 2
+
+// ErrUnsupportedVersion is returned when the input has a format or schema version that cannot be decoded.
+var ErrUnsupportedVersion = errors.New("goreg: unsupported version")
+
+// Migration migrates the JSON encoding of a value from one schema version to the next.
+type Migration func(old json.RawMessage) (json.RawMessage, error)
+
+// Migrations is a set of migrations between consecutive schema versions.
+// The zero value is an empty set ready to use.
+type Migrations struct {
+	steps map[int]Migration
+}
+
+// NewMigrations creates a new empty [Migrations].
+func NewMigrations() *Migrations {
+	return &Migrations{}
+}
+
+// Register registers the migration from schema version from to version from+1, replacing any existing one.
+func (m *Migrations) Register(from int, fn Migration) {
+	if m.steps == nil {
+		m.steps = make(map[int]Migration)
+	}
+	m.steps[from] = fn
+}
+
+// Migrate runs the migrations from schema version from up to version to in order.
+// A nil *Migrations only accepts from == to.
+func (m *Migrations) Migrate(value json.RawMessage, from, to int) (json.RawMessage, error) {
+	if from > to {
+		return nil, fmt.Errorf("%w: schema version %d is newer than %d", ErrUnsupportedVersion, from, to)
+	}
+	for v := from; v < to; v++ {
+		if err := m.check(v); err != nil {
+			return nil, err
+		}
+
+		var err error
+		value, err = m.steps[v](value)
+		if err != nil {
+			return nil, fmt.Errorf("goreg: migrating from schema version %d: %w", v, err)
+		}
+	}
+	return value, nil
+}
+
+// needed reports whether any migrations run between schema versions from and to.
+func (m *Migrations) needed(from, to int) bool {
+	if m == nil {
+		return false
+	}
+	for v := from; v < to; v++ {
+		if _, ok := m.steps[v]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Migrations) check(v int) error {
+	if m == nil || m.steps[v] == nil {
+		return fmt.Errorf("%w: no migration from schema version %d", ErrUnsupportedVersion, v)
+	}
+	return nil
+}
+
+// jsonEnvelope is the envelope written by [VersionedJSONCodec].
+type jsonEnvelope struct {
+	Format  int             `json:"format"`
+	Schema  int             `json:"schema"`
+	Entries json.RawMessage `json:"entries"`
+}
+
+// VersionedJSONCodec is a [Codec] that wraps the JSON array format of [JSONCodec] in an envelope
+// carrying the format version and a user schema version:
+//
+//	{"format": 1, "schema": 2, "entries": [{"key": "door", "value": ...}]}
+//
+// When decoding input with an older schema version, every value is passed through Migrations before it is
+// unmarshaled. Input without an envelope, in either the array or the object format, is read as schema version 0.
+// Since an object with only "format", "schema" and "entries" keys is read as an envelope,
+// such registries should not be stored in the object format without one.
+type VersionedJSONCodec[T any] struct {
+	// Schema is the current schema version of the values, written when encoding.
+	Schema int
+
+	// Migrations migrates values from older schema versions. May be nil if there are none.
+	Migrations *Migrations
+
+	// Indent is the indentation used for each level. An empty Indent produces compact output.
+	Indent string
+}
+
+// Encode writes the entries to w.
+func (c VersionedJSONCodec[T]) Encode(w io.Writer, entries []Entry[T]) error {
+	if entries == nil {
+		entries = []Entry[T]{}
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", c.Indent)
+	return enc.Encode(jsonEnvelope{Format: FormatVersion, Schema: c.Schema, Entries: data})
+}
+
+// Decode reads entries from r, migrating them to the current schema version.
+func (c VersionedJSONCodec[T]) Decode(r io.Reader) ([]Entry[T], error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := 0
+	if env, ok := parseJSONEnvelope(data); ok {
+		if env.Format > FormatVersion {
+			return nil, fmt.Errorf("%w: format version %d is newer than %d", ErrUnsupportedVersion, env.Format, FormatVersion)
+		}
+		if env.Schema < 0 {
+			return nil, fmt.Errorf("%w: invalid schema version %d", ErrUnsupportedVersion, env.Schema)
+		}
+		schema, data = env.Schema, env.Entries
+	}
+	if schema > c.Schema {
+		return nil, fmt.Errorf("%w: schema version %d is newer than %d", ErrUnsupportedVersion, schema, c.Schema)
+	}
+
+	raw, err := decodeJSON[json.RawMessage](bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return migrateEntries[T](raw, c.Migrations, schema, c.Schema)
+}
+
+// migrateEntries migrates the JSON-encoded values in raw from schema version from to version to and unmarshals them.
+func migrateEntries[T any](raw []Entry[json.RawMessage], m *Migrations, from, to int) ([]Entry[T], error) {
+	entries := make([]Entry[T], 0, len(raw))
+	for i, e := range raw {
+		value, err := m.Migrate(e.Value, from, to)
+		if err != nil {
+			return nil, &DecodeError{Index: i, Key: e.Key, Offset: -1, Err: err}
+		}
+
+		var obj T
+		if err := json.Unmarshal(value, &obj); err != nil {
+			return nil, &DecodeError{Index: i, Key: e.Key, Offset: -1, Err: err}
+		}
+		entries = append(entries, Entry[T]{Key: e.Key, Value: obj})
+	}
+	return entries, nil
+}
+
+// parseJSONEnvelope parses data as a [jsonEnvelope]. It only succeeds if data is an object with a positive
+// integer "format", an optional integer "schema", an "entries" array and no other keys.
+func parseJSONEnvelope(data []byte) (jsonEnvelope, bool) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return jsonEnvelope{}, false
+	}
+	for key := range fields {
+		if key != "format" && key != "schema" && key != "entries" {
+			return jsonEnvelope{}, false
+		}
+	}
+
+	var env jsonEnvelope
+	if err := json.Unmarshal(fields["format"], &env.Format); err != nil || env.Format < 1 {
+		return jsonEnvelope{}, false
+	}
+	if schema, ok := fields["schema"]; ok {
+		if err := json.Unmarshal(schema, &env.Schema); err != nil {
+			return jsonEnvelope{}, false
+		}
+	}
+	env.Entries = bytes.TrimSpace(fields["entries"])
+	if len(env.Entries) == 0 || env.Entries[0] != '[' {
+		return jsonEnvelope{}, false
+	}
+	return env, true
+}
+
+// gobEnvelope is the envelope written by [VersionedGobCodec].
+type gobEnvelope struct {
+	Format int
+	Schema int
+	Values []Entry[json.RawMessage]
+}
+
+// gobEnvelopeV1 is the envelope of format version 1, which held gob-encoded values.
+type gobEnvelopeV1[T any] struct {
+	Format  int
+	Schema  int
+	Entries []Entry[T]
+}
+
+// VersionedGobCodec is a [Codec] that wraps the entries in a gob-encoded envelope
+// carrying the format version and a user schema version.
+//
+// Values are stored as JSON inside the envelope, so they must be JSON-encodable, and input with an older schema
+// version is migrated by the same [Migration] steps as with [VersionedJSONCodec].
+//
+// Input written by [GobCodec], [StandardRegistry.GobEncode] or [OrderedRegistry.GobEncode] is read as schema version 0,
+// and like the envelopes of format version 1, it holds gob-encoded values that can't be migrated. They are decoded
+// straight into the current type, relying on gob matching struct fields by name, and rejected with
+// [ErrUnsupportedVersion] if Migrations has steps registered between their schema version and the current one.
+type VersionedGobCodec[T any] struct {
+	// Schema is the current schema version of the values, written when encoding.
+	Schema int
+
+	// Migrations migrates values from older schema versions. May be nil if there are none.
+	Migrations *Migrations
+}
+
+// Encode writes the entries to w.
+func (c VersionedGobCodec[T]) Encode(w io.Writer, entries []Entry[T]) error {
+	values := make([]Entry[json.RawMessage], 0, len(entries))
+	for _, e := range entries {
+		data, err := json.Marshal(e.Value)
+		if err != nil {
+			return fmt.Errorf("goreg: encoding %q: %w", e.Key, err)
+		}
+		values = append(values, Entry[json.RawMessage]{Key: e.Key, Value: data})
+	}
+	return gob.NewEncoder(w).Encode(gobEnvelope{Format: gobFormatVersion, Schema: c.Schema, Values: values})
+}
+
+// Decode reads entries from r, migrating them to the current schema version.
+func (c VersionedGobCodec[T]) Decode(r io.Reader) ([]Entry[T], error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var env gobEnvelope
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&env); err != nil || env.Format < 2 {
+		return c.decodeGobValues(data)
+	}
+	if err := c.checkVersion(env.Format, env.Schema); err != nil {
+		return nil, err
+	}
+	return migrateEntries[T](env.Values, c.Migrations, env.Schema, c.Schema)
+}
+
+// decodeGobValues decodes input holding gob-encoded values, an envelope of format version 1 or no envelope at all.
+func (c VersionedGobCodec[T]) decodeGobValues(data []byte) ([]Entry[T], error) {
+	var env gobEnvelopeV1[T]
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&env); err != nil {
+		if env, err = decodeLegacyGob[T](data); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.checkVersion(env.Format, env.Schema); err != nil {
+		return nil, err
+	}
+	if c.Migrations.needed(env.Schema, c.Schema) {
+		return nil, fmt.Errorf("%w: gob-encoded values of schema version %d can't be migrated", ErrUnsupportedVersion, env.Schema)
+	}
+	return env.Entries, nil
+}
+
+func (c VersionedGobCodec[T]) checkVersion(format, schema int) error {
+	if format > gobFormatVersion {
+		return fmt.Errorf("%w: format version %d is newer than %d", ErrUnsupportedVersion, format, gobFormatVersion)
+	}
+	if schema < 0 {
+		return fmt.Errorf("%w: invalid schema version %d", ErrUnsupportedVersion, schema)
+	}
+	if schema > c.Schema {
+		return fmt.Errorf("%w: schema version %d is newer than %d", ErrUnsupportedVersion, schema, c.Schema)
+	}
+	return nil
+}
+
+// decodeLegacyGob decodes data written without an envelope, either as a slice of entries or as a map.
+func decodeLegacyGob[T any](data []byte) (gobEnvelopeV1[T], error) {
+	var entries []Entry[T]
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&entries)
+	if err == nil {
+		return gobEnvelopeV1[T]{Entries: entries}, nil
+	}
+
+	var objs map[string]T
+	if gob.NewDecoder(bytes.NewReader(data)).Decode(&objs) != nil {
+		return gobEnvelopeV1[T]{}, err
+	}
+	for _, id := range slices.Sorted(maps.Keys(objs)) {
+		entries = append(entries, Entry[T]{Key: id, Value: objs[id]})
+	}
+	return gobEnvelopeV1[T]{Entries: entries}, nil
+}
@@ -0,0 +1,221 @@
+package goreg_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/MatusOllah/goreg"
+)
+
+type levelV2 struct {
+	Title  string `json:"title"`
+	Floors int    `json:"floors"`
+}
+
+func levelMigrations() *goreg.Migrations {
+	m := goreg.NewMigrations()
+	// Version 0 stored the level as a plain string.
+	m.Register(0, func(old json.RawMessage) (json.RawMessage, error) {
+		return json.Marshal(map[string]json.RawMessage{"name": old})
+	})
+	// Version 1 renamed "name" to "title" and added "floors".
+	m.Register(1, func(old json.RawMessage) (json.RawMessage, error) {
+		var v1 struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(old, &v1); err != nil {
+			return nil, err
+		}
+		return json.Marshal(levelV2{Title: v1.Name, Floors: 1})
+	})
+	return m
+}
+
+func TestVersionedJSONCodec(t *testing.T) {
+	testCodecRoundTrip(t, goreg.VersionedJSONCodec[int]{})
+	testCodecRoundTrip(t, goreg.VersionedJSONCodec[int]{Schema: 3, Indent: "\t"})
+}
+
+func TestVersionedJSONCodec_Encode(t *testing.T) {
+	reg := goreg.NewOrderedRegistry[int]()
+	reg.Register("kajsmentke", 42)
+
+	var bf bytes.Buffer
+	if err := goreg.Encode(reg, goreg.VersionedJSONCodec[int]{Schema: 2}, &bf); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+
+	expected := `{"format":1,"schema":2,"entries":[{"key":"kajsmentke","value":42}]}` + "\n"
+	if bf.String() != expected {
+		t.Errorf("expected %s, got %s", expected, bf.String())
+	}
+}
+
+func TestVersionedJSONCodec_Migrate(t *testing.T) {
+	codec := goreg.VersionedJSONCodec[levelV2]{Schema: 2, Migrations: levelMigrations()}
+	expected := []goreg.Entry[levelV2]{{Key: "lobby", Value: levelV2{Title: "Lobby", Floors: 1}}}
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"Version0Array", `[{"key":"lobby","value":"Lobby"}]`},
+		{"Version0Object", `{"lobby":"Lobby"}`},
+		{"Version1", `{"format":1,"schema":1,"entries":[{"key":"lobby","value":{"name":"Lobby"}}]}`},
+		{"Version2", `{"format":1,"schema":2,"entries":[{"key":"lobby","value":{"title":"Lobby","floors":1}}]}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entries, err := codec.Decode(strings.NewReader(test.data))
+			if err != nil {
+				t.Fatalf("failed to decode: %v", err)
+			}
+			if len(entries) != 1 || entries[0] != expected[0] {
+				t.Errorf("expected %v, got %v", expected, entries)
+			}
+		})
+	}
+}
+
+func TestVersionedJSONCodec_Unsupported(t *testing.T) {
+	codec := goreg.VersionedJSONCodec[levelV2]{Schema: 2, Migrations: levelMigrations()}
+
+	for _, data := range []string{
+		`{"format":2,"schema":2,"entries":[]}`,
+		`{"format":1,"schema":3,"entries":[]}`,
+		`{"format":1,"schema":-1,"entries":[{"key":"lobby","value":"Lobby"}]}`,
+	} {
+		if _, err := codec.Decode(strings.NewReader(data)); !errors.Is(err, goreg.ErrUnsupportedVersion) {
+			t.Errorf("expected ErrUnsupportedVersion for %s, got %v", data, err)
+		}
+	}
+
+	// Without migrations, older schema versions can't be read.
+	codec.Migrations = nil
+	if _, err := codec.Decode(strings.NewReader(`{"lobby":"Lobby"}`)); !errors.Is(err, goreg.ErrUnsupportedVersion) {
+		t.Errorf("expected ErrUnsupportedVersion, got %v", err)
+	}
+}
+
+func TestVersionedJSONCodec_MigrationError(t *testing.T) {
+	m := goreg.NewMigrations()
+	m.Register(0, func(old json.RawMessage) (json.RawMessage, error) {
+		return nil, errors.New("boom")
+	})
+
+	_, err := goreg.VersionedJSONCodec[int]{Schema: 1, Migrations: m}.Decode(strings.NewReader(`{"a":1}`))
+	var decErr *goreg.DecodeError
+	if !errors.As(err, &decErr) || decErr.Key != "a" {
+		t.Errorf("expected DecodeError for key a, got %v", err)
+	}
+}
+
+func TestVersionedGobCodec(t *testing.T) {
+	testCodecRoundTrip(t, goreg.VersionedGobCodec[int]{})
+	testCodecRoundTrip(t, goreg.VersionedGobCodec[int]{Schema: 3})
+}
+
+func TestVersionedGobCodec_Legacy(t *testing.T) {
+	ordered := goreg.NewOrderedRegistry[int]()
+	ordered.Register("kozmeker", 69)
+	ordered.Register("kajsmentke", 42)
+
+	standard := goreg.NewStandardRegistry[int]()
+	standard.Register("kajsmentke", 42)
+	standard.Register("kozmeker", 69)
+
+	var codecData bytes.Buffer
+	if err := goreg.Encode(ordered, goreg.GobCodec[int]{}, &codecData); err != nil {
+		t.Fatal(err)
+	}
+	orderedData, err := ordered.GobEncode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	standardData, err := standard.GobEncode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, data := range map[string][]byte{"GobCodec": codecData.Bytes(), "Ordered": orderedData, "Standard": standardData} {
+		t.Run(name, func(t *testing.T) {
+			reg := goreg.NewStandardRegistry[int]()
+			if err := goreg.Decode(reg, goreg.VersionedGobCodec[int]{Schema: 1}, bytes.NewReader(data)); err != nil {
+				t.Fatalf("failed to decode: %v", err)
+			}
+			if !goreg.Equal[int](reg, standard) {
+				t.Errorf("expected %s, got %s", standard, reg)
+			}
+		})
+	}
+}
+
+func TestVersionedGobCodec_Migrate(t *testing.T) {
+	reg := goreg.NewStandardRegistry[string]()
+	reg.Register("lobby", "Lobby")
+
+	var bf bytes.Buffer
+	if err := goreg.Encode(reg, goreg.VersionedGobCodec[string]{}, &bf); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := goreg.VersionedGobCodec[levelV2]{Schema: 2, Migrations: levelMigrations()}.Decode(bytes.NewReader(bf.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	expected := levelV2{Title: "Lobby", Floors: 1}
+	if len(entries) != 1 || entries[0].Key != "lobby" || entries[0].Value != expected {
+		t.Errorf("expected lobby=%v, got %v", expected, entries)
+	}
+}
+
+func TestVersionedGobCodec_Unsupported(t *testing.T) {
+	reg := goreg.NewStandardRegistry[int]()
+	reg.Register("kajsmentke", 42)
+
+	var bf bytes.Buffer
+	if err := goreg.Encode(reg, goreg.VersionedGobCodec[int]{Schema: 2}, &bf); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := (goreg.VersionedGobCodec[int]{Schema: 1}).Decode(bytes.NewReader(bf.Bytes())); !errors.Is(err, goreg.ErrUnsupportedVersion) {
+		t.Errorf("expected ErrUnsupportedVersion for newer schema, got %v", err)
+	}
+	// Without migrations, older schema versions can't be read.
+	if _, err := (goreg.VersionedGobCodec[int]{Schema: 3}).Decode(bytes.NewReader(bf.Bytes())); !errors.Is(err, goreg.ErrUnsupportedVersion) {
+		t.Errorf("expected ErrUnsupportedVersion without migrations, got %v", err)
+	}
+}
+
+func TestVersionedGobCodec_Format1(t *testing.T) {
+	// Format version 1 held gob-encoded values.
+	var bf bytes.Buffer
+	env := struct {
+		Format  int
+		Schema  int
+		Entries []goreg.Entry[int]
+	}{1, 2, []goreg.Entry[int]{{Key: "kajsmentke", Value: 42}}}
+	if err := gob.NewEncoder(&bf).Encode(env); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := goreg.VersionedGobCodec[int]{Schema: 3}.Decode(bytes.NewReader(bf.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Value != 42 {
+		t.Errorf("expected kajsmentke=42, got %v", entries)
+	}
+
+	// Gob-encoded values can't be migrated.
+	m := goreg.NewMigrations()
+	m.Register(2, func(old json.RawMessage) (json.RawMessage, error) { return old, nil })
+	if _, err := (goreg.VersionedGobCodec[int]{Schema: 3, Migrations: m}).Decode(bytes.NewReader(bf.Bytes())); !errors.Is(err, goreg.ErrUnsupportedVersion) {
+		t.Errorf("expected ErrUnsupportedVersion when migrations are needed, got %v", err)
+	}
+}
@@ -0,0 +1,389 @@
+package goreg
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Watch event names.
+const (
+	watchRegistered   = "registered"
+	watchUnregistered = "unregistered"
+	watchReset        = "reset"
+)
+
+// watchEvent is the data of a watch event.
+type watchEvent[T any] struct {
+	ID    string `json:"id,omitempty"`
+	Value *T     `json:"value,omitempty"`
+}
+
+// watchEventData is a decoded [watchEvent]. The value is kept raw, so that a missing value can be told apart from null.
+type watchEventData struct {
+	ID    string          `json:"id"`
+	Value json.RawMessage `json:"value"`
+}
+
+// WatchHandlerOptions configures the handler returned by [NewWatchHandler].
+type WatchHandlerOptions struct {
+	// Authorize wraps the handler, usually to check the credentials of a request before passing it on.
+	Authorize func(next http.Handler) http.Handler
+
+	// KeepAlive is the interval of comments sent to keep idle connections open. Defaults to 15 seconds.
+	KeepAlive time.Duration
+}
+
+type watchHandler[T any] struct {
+	feed  *FeedRegistry[T]
+	opts  WatchHandlerOptions
+	epoch string
+}
+
+// NewWatchHandler returns an [http.Handler] that streams the changes of feed as Server-Sent Events.
+//
+// Every change is sent as a registered, unregistered or reset event whose data is a JSON object with the ID
+// and the value, and whose event ID is the sequence number of the change, prefixed with a random epoch of the handler.
+// A client reconnecting with a Last-Event-ID header only gets the changes it missed. New clients, clients that fell
+// out of the feed and clients of another handler, such as one from before a restart, first get a reset event
+// followed by a registered event for every entry.
+//
+// A nil opts is equivalent to a zero [WatchHandlerOptions].
+func NewWatchHandler[T any](feed *FeedRegistry[T], opts *WatchHandlerOptions) http.Handler {
+	h := &watchHandler[T]{feed: feed, epoch: newEpoch()}
+	if opts != nil {
+		h.opts = *opts
+	}
+	if h.opts.KeepAlive <= 0 {
+		h.opts.KeepAlive = 15 * time.Second
+	}
+
+	if h.opts.Authorize != nil {
+		return h.opts.Authorize(h)
+	}
+	return h
+}
+
+func (h *watchHandler[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		writeHTTPError(w, http.StatusMethodNotAllowed, errors.New("goreg: method not allowed"))
+		return
+	}
+
+	// Event IDs of another epoch, or that can't be parsed, refer to a different history, so the client gets a snapshot.
+	seq, resume := uint64(0), false
+	if epoch, n, ok := parseWatchEventID(r.Header.Get("Last-Event-ID")); ok && epoch == h.epoch {
+		seq, resume = n, true
+	}
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	ticker := time.NewTicker(h.opts.KeepAlive)
+	defer ticker.Stop()
+
+	for {
+		// Get the channel first, so that no change made after ChangesSince is missed.
+		changed := h.feed.Changed()
+
+		var err error
+		if changes, feedErr := h.feed.ChangesSince(seq); resume && feedErr == nil {
+			for _, c := range changes {
+				if err = writeWatchChange(w, h.epoch, c); err != nil {
+					break
+				}
+			}
+			if len(changes) > 0 {
+				seq = changes[len(changes)-1].Seq
+			}
+		} else {
+			seq, err = h.writeSnapshot(w)
+			resume = true
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-ticker.C:
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeSnapshot writes a reset event followed by the entries of the feed. Only the last event carries an event ID,
+// so a client disconnected in the middle of a snapshot gets a new one.
+func (h *watchHandler[T]) writeSnapshot(w io.Writer) (uint64, error) {
+	entries, seq := h.feed.Snapshot()
+	id := watchEventID(h.epoch, seq)
+
+	resetID := ""
+	if len(entries) == 0 {
+		resetID = id
+	}
+	if err := writeWatchEvent(w, resetID, watchReset, watchEvent[T]{}); err != nil {
+		return 0, err
+	}
+	for i, e := range entries {
+		eventID := ""
+		if i == len(entries)-1 {
+			eventID = id
+		}
+		if err := writeWatchEvent(w, eventID, watchRegistered, watchEvent[T]{ID: e.Key, Value: &e.Value}); err != nil {
+			return 0, err
+		}
+	}
+	return seq, nil
+}
+
+// watchEventID returns the event ID of the change with the sequence number seq.
+func watchEventID(epoch string, seq uint64) string {
+	return epoch + "-" + strconv.FormatUint(seq, 10)
+}
+
+// parseWatchEventID parses an event ID returned by watchEventID.
+func parseWatchEventID(id string) (epoch string, seq uint64, ok bool) {
+	epoch, s, ok := strings.Cut(id, "-")
+	if !ok {
+		return "", 0, false
+	}
+	seq, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return epoch, seq, true
+}
+
+func writeWatchChange[T any](w io.Writer, epoch string, c Change[T]) error {
+	id := watchEventID(epoch, c.Seq)
+	switch c.Op {
+	case OpRegister:
+		return writeWatchEvent(w, id, watchRegistered, watchEvent[T]{ID: c.ID, Value: &c.Value})
+	case OpUnregister:
+		return writeWatchEvent(w, id, watchUnregistered, watchEvent[T]{ID: c.ID})
+	default:
+		return writeWatchEvent(w, id, watchReset, watchEvent[T]{})
+	}
+}
+
+func writeWatchEvent[T any](w io.Writer, id, event string, data watchEvent[T]) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b)
+	return err
+}
+
+// WatchOptions configures a [Watcher].
+type WatchOptions struct {
+	// Client is the HTTP client used for requests. It must not have a timeout, since the stream is long-lived.
+	// Defaults to [http.DefaultClient].
+	Client *http.Client
+
+	// Header is added to every request, for example to carry credentials.
+	Header http.Header
+
+	// Backoff is the delay before the first reconnect. It doubles with every failed attempt, up to 30 seconds.
+	// Defaults to 1 second.
+	Backoff time.Duration
+
+	// OnError is called when the stream fails and the watcher is about to reconnect.
+	// Defaults to logging the error with [log/slog].
+	OnError func(err error)
+}
+
+// Watcher mirrors a registry streamed by [NewWatchHandler] into a local registry.
+type Watcher[T any] struct {
+	url  string
+	reg  Registry[T]
+	opts WatchOptions
+
+	seq    atomic.Uint64
+	resume atomic.Bool
+	epoch  string // epoch of the last event ID, only accessed by Run
+}
+
+// NewWatcher creates a new [Watcher] mirroring the watch handler at url into reg.
+// reg should not be mutated by anything else.
+//
+// A nil opts is equivalent to a zero [WatchOptions].
+func NewWatcher[T any](url string, reg Registry[T], opts *WatchOptions) *Watcher[T] {
+	w := &Watcher[T]{url: url, reg: reg}
+	if opts != nil {
+		w.opts = *opts
+	}
+	if w.opts.Client == nil {
+		w.opts.Client = http.DefaultClient
+	}
+	if w.opts.Backoff <= 0 {
+		w.opts.Backoff = time.Second
+	}
+	if w.opts.OnError == nil {
+		w.opts.OnError = func(err error) {
+			slog.Error("*goreg.Watcher: watch failed", "err", err)
+		}
+	}
+	return w
+}
+
+// Seq returns the sequence number of the last change applied.
+func (w *Watcher[T]) Seq() uint64 {
+	return w.seq.Load()
+}
+
+// Run mirrors the registry until ctx is done, reconnecting and resuming from the last event after failures.
+// It returns ctx.Err(), or a [*RemoteError] if the server rejects the request with a client error other than 429.
+func (w *Watcher[T]) Run(ctx context.Context) error {
+	backoff := w.opts.Backoff
+	for {
+		received, err := w.stream(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var remoteErr *RemoteError
+		if errors.As(err, &remoteErr) && remoteErr.StatusCode < 500 && remoteErr.StatusCode != http.StatusTooManyRequests {
+			return err
+		}
+		if err == nil {
+			err = io.ErrUnexpectedEOF
+		}
+		w.opts.OnError(err)
+
+		if received {
+			backoff = w.opts.Backoff
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff = min(backoff*2, 30*time.Second)
+	}
+}
+
+// stream reads events from a single connection. received reports whether any event was applied.
+func (w *Watcher[T]) stream(ctx context.Context) (received bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, w.url, nil)
+	if err != nil {
+		return false, err
+	}
+	for k, v := range w.opts.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if w.resume.Load() {
+		req.Header.Set("Last-Event-ID", watchEventID(w.epoch, w.seq.Load()))
+	}
+
+	resp, err := w.opts.Client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, readRemoteError(resp)
+	}
+	defer resp.Body.Close()
+
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(nil, maxFrameSize)
+	var id, event string
+	var data strings.Builder
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" {
+			if event != "" || data.Len() > 0 {
+				if err := w.apply(event, data.String()); err != nil {
+					return received, err
+				}
+				received = true
+				if id == "" && event == watchReset {
+					// A snapshot has started; until it is complete, a reconnect needs a new one.
+					w.resume.Store(false)
+				}
+				if id != "" {
+					epoch, n, ok := parseWatchEventID(id)
+					if !ok {
+						return received, fmt.Errorf("goreg: invalid event ID %q", id)
+					}
+					w.epoch = epoch
+					w.seq.Store(n)
+					w.resume.Store(true)
+				}
+			}
+			id, event = "", ""
+			data.Reset()
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			id = value
+		case "event":
+			event = value
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		}
+	}
+	return received, sc.Err()
+}
+
+func (w *Watcher[T]) apply(event, data string) error {
+	var e watchEventData
+	if err := json.Unmarshal([]byte(data), &e); err != nil {
+		return fmt.Errorf("goreg: decoding %s event: %w", event, err)
+	}
+
+	switch event {
+	case watchRegistered:
+		if e.Value == nil {
+			return fmt.Errorf("goreg: %s event for %q without a value", event, e.ID)
+		}
+		// A null value is a valid nil pointer, interface, map or slice.
+		var obj T
+		if err := json.Unmarshal(e.Value, &obj); err != nil {
+			return fmt.Errorf("goreg: decoding %s event for %q: %w", event, e.ID, err)
+		}
+		w.reg.Register(e.ID, obj)
+	case watchUnregistered:
+		w.reg.Unregister(e.ID)
+	case watchReset:
+		w.reg.Reset()
+	default:
+		return fmt.Errorf("goreg: unknown event %q", event)
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package goreg_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/goreg"
+)
+
+// waitFor waits until cond is true.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// startWatcher runs a watcher mirroring the watch handler at url into reg. The returned function stops it
+// and returns the result of Run.
+func startWatcher(url string, reg goreg.Registry[int]) (*goreg.Watcher[int], func() error) {
+	watcher := goreg.NewWatcher[int](url, reg, &goreg.WatchOptions{Backoff: time.Millisecond, OnError: func(error) {}})
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- watcher.Run(ctx)
+	}()
+	return watcher, func() error {
+		cancel()
+		return <-runErr
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	feed := goreg.NewFeedRegistry[int](goreg.NewStandardRegistry[int](), nil)
+	feed.Register("kozmeker", 69)
+	feed.Register("kajsmentke", 42)
+	srv := httptest.NewServer(goreg.NewWatchHandler(feed, nil))
+	defer srv.Close()
+
+	mirror := goreg.NewStandardRegistry[int]()
+	mirror.Register("invalid", 0)
+	watcher, stop := startWatcher(srv.URL, mirror)
+
+	waitFor(t, func() bool { return watcher.Seq() == 2 })
+	feed.Unregister("kozmeker")
+	feed.Register("kocurkovo", 1)
+	waitFor(t, func() bool { return watcher.Seq() == 4 })
+
+	if !maps.Equal(goreg.Collect[int](mirror), goreg.Collect[int](feed)) {
+		t.Errorf("expected %s, got %s", feed, mirror)
+	}
+
+	feed.Reset()
+	waitFor(t, func() bool { return watcher.Seq() == 5 })
+	if mirror.Len() != 0 {
+		t.Errorf("expected length 0, got %d", mirror.Len())
+	}
+
+	if err := stop(); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+// readEvents reads n events from an event stream, returning their lines.
+func readEvents(t *testing.T, sc *bufio.Scanner, n int) []string {
+	t.Helper()
+	var lines []string
+	for n > 0 {
+		if !sc.Scan() {
+			t.Fatalf("stream ended early: %v", sc.Err())
+		}
+		lines = append(lines, sc.Text())
+		if sc.Text() == "" {
+			n--
+		}
+	}
+	return lines
+}
+
+// watch connects to the watch handler at url with the Last-Event-ID header set to lastID, if not empty.
+func watch(t *testing.T, url, lastID string) *bufio.Scanner {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("expected text/event-stream, got %s", ct)
+	}
+	return bufio.NewScanner(resp.Body)
+}
+
+func TestWatchHandler_LastEventID(t *testing.T) {
+	feed := goreg.NewFeedRegistry[int](goreg.NewStandardRegistry[int](), nil)
+	feed.Register("kozmeker", 69)
+	feed.Register("kajsmentke", 42)
+	feed.Unregister("kozmeker")
+	srv := httptest.NewServer(goreg.NewWatchHandler(feed, nil))
+	t.Cleanup(srv.Close) // after the streams are closed
+
+	// A new client gets a snapshot, whose last event ID carries the epoch of the handler.
+	snapshot := readEvents(t, watch(t, srv.URL, ""), 2)
+	id, ok := strings.CutPrefix(snapshot[3], "id: ")
+	if !ok {
+		t.Fatalf("expected an event ID, got %q", snapshot)
+	}
+	epoch, _, _ := strings.Cut(id, "-")
+	if id != epoch+"-3" {
+		t.Errorf("expected event ID of change 3, got %q", id)
+	}
+
+	expected := []string{
+		"id: " + epoch + "-2", "event: registered", `data: {"id":"kajsmentke","value":42}`, "",
+		"id: " + epoch + "-3", "event: unregistered", `data: {"id":"kozmeker"}`, "",
+	}
+	if got := readEvents(t, watch(t, srv.URL, epoch+"-1"), 2); !slices.Equal(got, expected) {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	// Event IDs of another epoch get a snapshot.
+	for _, lastID := range []string{"1", "0123456789abcdef-1", "invalid"} {
+		if got := readEvents(t, watch(t, srv.URL, lastID), 1); got[0] != "event: reset" {
+			t.Errorf("expected a snapshot for Last-Event-ID %q, got %q", lastID, got)
+		}
+	}
+}
+
+func TestWatcher_RestartedServer(t *testing.T) {
+	feed := goreg.NewFeedRegistry[int](goreg.NewStandardRegistry[int](), nil)
+	feed.Register("kozmeker", 69)
+	feed.Register("kajsmentke", 42)
+	var h atomic.Value
+	h.Store(goreg.NewWatchHandler(feed, nil))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.Load().(http.Handler).ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	mirror := goreg.NewStandardRegistry[int]()
+	watcher, stop := startWatcher(srv.URL, mirror)
+	defer stop()
+	waitFor(t, func() bool { return watcher.Seq() == 2 })
+
+	// The restarted server has a different history at the same sequence numbers.
+	restarted := goreg.NewFeedRegistry[int](goreg.NewStandardRegistry[int](), nil)
+	restarted.Register("kocurkovo", 1)
+	restarted.Register("lopata", 7)
+	restarted.Register("bager", 3)
+	h.Store(goreg.NewWatchHandler(restarted, nil))
+	srv.CloseClientConnections()
+
+	waitFor(t, func() bool { return watcher.Seq() == 3 })
+	if !maps.Equal(goreg.Collect[int](mirror), goreg.Collect[int](restarted)) {
+		t.Errorf("expected snapshot %s, got %s", restarted, mirror)
+	}
+}
+
+func TestWatcher_Reconnect(t *testing.T) {
+	feed := goreg.NewFeedRegistry[int](goreg.NewStandardRegistry[int](), &goreg.FeedOptions{Capacity: 2})
+	feed.Register("kozmeker", 69)
+	srv := httptest.NewServer(goreg.NewWatchHandler(feed, nil))
+	defer srv.Close()
+
+	mirror := goreg.NewStandardRegistry[int]()
+	watcher, stop := startWatcher(srv.URL, mirror)
+	defer stop()
+	waitFor(t, func() bool { return watcher.Seq() == 1 })
+
+	// Missed changes are resumed without a snapshot.
+	mirror.Register("local", 1)
+	srv.CloseClientConnections()
+	feed.Register("kajsmentke", 42)
+	waitFor(t, func() bool { return watcher.Seq() == 2 })
+	if _, ok := mirror.Get("local"); !ok {
+		t.Error("expected resume to keep the local registry instead of taking a snapshot")
+	}
+
+	// Falling out of the feed gets a snapshot.
+	srv.CloseClientConnections()
+	for i := range 5 {
+		feed.Register("kocurkovo", i)
+	}
+	waitFor(t, func() bool { return watcher.Seq() == 7 })
+	if !maps.Equal(goreg.Collect[int](mirror), goreg.Collect[int](feed)) {
+		t.Errorf("expected snapshot %s, got %s", feed, mirror)
+	}
+}
+
+func TestWatcher_Unauthorized(t *testing.T) {
+	feed := goreg.NewFeedRegistry[int](goreg.NewStandardRegistry[int](), nil)
+	deny := func(http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+		})
+	}
+	srv := httptest.NewServer(goreg.NewWatchHandler(feed, &goreg.WatchHandlerOptions{Authorize: deny}))
+	defer srv.Close()
+
+	err := goreg.NewWatcher[int](srv.URL, goreg.NewStandardRegistry[int](), nil).Run(context.Background())
+	var remoteErr *goreg.RemoteError
+	if !errors.As(err, &remoteErr) || remoteErr.StatusCode != http.StatusForbidden || !strings.Contains(remoteErr.Message, "forbidden") {
+		t.Errorf("expected 403 RemoteError, got %v", err)
+	}
+}
+
+func TestWatcher_NilValue(t *testing.T) {
+	feed := goreg.NewFeedRegistry[*int](goreg.NewStandardRegistry[*int](), nil)
+	feed.Register("kozmeker", nil)
+	n := 42
+	feed.Register("kajsmentke", &n)
+	srv := httptest.NewServer(goreg.NewWatchHandler(feed, nil))
+	defer srv.Close()
+
+	var errs atomic.Int64
+	mirror := goreg.NewStandardRegistry[*int]()
+	watcher := goreg.NewWatcher[*int](srv.URL, mirror, &goreg.WatchOptions{Backoff: time.Millisecond, OnError: func(error) { errs.Add(1) }})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watcher.Run(ctx)
+
+	waitFor(t, func() bool { return watcher.Seq() == 2 })
+	feed.Register("kocurkovo", nil)
+	waitFor(t, func() bool { return watcher.Seq() == 3 })
+
+	for _, id := range []string{"kozmeker", "kocurkovo"} {
+		if val, ok := mirror.Get(id); !ok || val != nil {
+			t.Errorf("expected nil %s, got %v", id, val)
+		}
+	}
+	if val, ok := mirror.Get("kajsmentke"); !ok || val == nil || *val != 42 {
+		t.Errorf("expected 42, got %v", val)
+	}
+	if n := errs.Load(); n != 0 {
+		t.Errorf("expected no errors, got %d", n)
+	}
+}
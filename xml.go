@@ -0,0 +1,142 @@
+package goreg
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// XMLCodec is a [Codec] that encodes entries as XML with [encoding/xml].
+//
+// Every entry is encoded as an element carrying its ID in an attribute, with the value marshaled inside it:
+//
+//	<registry>
+//	  <entry key="door">Door</entry>
+//	</registry>
+//
+// Because the entry element name is set by the codec, values should not declare an XMLName of their own.
+type XMLCodec[T any] struct {
+	// RootName is the name of the root element. Defaults to "registry".
+	RootName string
+
+	// EntryName is the name of the entry elements. Defaults to "entry".
+	EntryName string
+
+	// KeyAttr is the name of the attribute holding the ID. Defaults to "key".
+	KeyAttr string
+
+	// Indent is the indentation used for each level. An empty Indent produces compact output.
+	Indent string
+}
+
+func (c XMLCodec[T]) names() (root, entry, key string) {
+	root, entry, key = c.RootName, c.EntryName, c.KeyAttr
+	if root == "" {
+		root = "registry"
+	}
+	if entry == "" {
+		entry = "entry"
+	}
+	if key == "" {
+		key = "key"
+	}
+	return
+}
+
+// Encode writes the entries to w.
+func (c XMLCodec[T]) Encode(w io.Writer, entries []Entry[T]) error {
+	rootName, entryName, keyAttr := c.names()
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", c.Indent)
+
+	root := xml.StartElement{Name: xml.Name{Local: rootName}}
+	if err := enc.EncodeToken(root); err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		start := xml.StartElement{
+			Name: xml.Name{Local: entryName},
+			Attr: []xml.Attr{{Name: xml.Name{Local: keyAttr}, Value: e.Key}},
+		}
+		if err := enc.EncodeElement(e.Value, start); err != nil {
+			return fmt.Errorf("goreg: encoding %q: %w", e.Key, err)
+		}
+	}
+
+	if err := enc.EncodeToken(root.End()); err != nil {
+		return err
+	}
+
+	return enc.Flush()
+}
+
+// Decode reads entries from r. Elements other than entry elements are skipped.
+func (c XMLCodec[T]) Decode(r io.Reader) ([]Entry[T], error) {
+	rootName, entryName, keyAttr := c.names()
+
+	dec := xml.NewDecoder(r)
+
+	// Find the root element.
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if se, ok := tok.(xml.StartElement); ok {
+			if se.Name.Local != rootName {
+				return nil, fmt.Errorf("goreg: expected <%s> root element, got <%s>", rootName, se.Name.Local)
+			}
+			break
+		}
+	}
+
+	var entries []Entry[T]
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			if tok.Name.Local != entryName {
+				if err := dec.Skip(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			id, ok := xmlAttr(tok, keyAttr)
+			if !ok {
+				line, col := dec.InputPos()
+				return nil, fmt.Errorf("goreg: <%s> element at line %d, column %d is missing the %q attribute", entryName, line, col, keyAttr)
+			}
+
+			var obj T
+			if err := dec.DecodeElement(&obj, &tok); err != nil {
+				return nil, fmt.Errorf("goreg: decoding %q: %w", id, err)
+			}
+			entries = append(entries, Entry[T]{Key: id, Value: obj})
+		case xml.EndElement:
+			return entries, nil
+		}
+	}
+}
+
+func xmlAttr(se xml.StartElement, name string) (string, bool) {
+	for _, attr := range se.Attr {
+		if attr.Name.Local == name {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,63 @@
+package goreg_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/MatusOllah/goreg"
+)
+
+func TestXMLCodec(t *testing.T) {
+	testCodecRoundTrip(t, goreg.XMLCodec[int]{})
+	testCodecRoundTrip(t, goreg.XMLCodec[int]{RootName: "things", EntryName: "thing", KeyAttr: "id", Indent: "  "})
+}
+
+func TestXMLCodec_Encode(t *testing.T) {
+	type Level struct {
+		Name  string `xml:"name"`
+		Stars int    `xml:"stars,attr"`
+	}
+
+	reg := goreg.NewOrderedRegistry[Level]()
+	reg.Register("level2", Level{Name: "Level 2", Stars: 3})
+	reg.Register("level1", Level{Name: "Level 1", Stars: 1})
+
+	var bf bytes.Buffer
+	if err := goreg.Encode(reg, goreg.XMLCodec[Level]{EntryName: "level"}, &bf); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+
+	expected := `<registry><level key="level2" stars="3"><name>Level 2</name></level><level key="level1" stars="1"><name>Level 1</name></level></registry>`
+	if bf.String() != expected {
+		t.Errorf("expected %s, got %s", expected, bf.String())
+	}
+
+	newReg := goreg.NewOrderedRegistry[Level]()
+	if err := goreg.Decode(newReg, goreg.XMLCodec[Level]{EntryName: "level"}, &bf); err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	if newReg.String() != reg.String() {
+		t.Errorf("expected %s, got %s", reg, newReg)
+	}
+}
+
+func TestXMLCodec_DecodeInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"Empty", ``},
+		{"Wrong root", `<things></things>`},
+		{"Missing key", `<registry><entry>42</entry></registry>`},
+		{"Unterminated", `<registry><entry key="a">42</entry>`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := (goreg.XMLCodec[int]{}).Decode(strings.NewReader(test.data)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}